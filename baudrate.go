@@ -0,0 +1,62 @@
+package sds011
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+
+	// DefaultBaudRate denotes the baud rate of the serial interface of a genuine
+	// device
+	DefaultBaudRate = 9600
+
+	// detectTimeout denotes the time to wait for a reply per attempted baud rate
+	detectTimeout = time.Second
+)
+
+// CommonBaudRates denotes the baud rates attempted by DetectBaudRate (in order)
+var CommonBaudRates = []int{DefaultBaudRate, 19200, 38400, 57600, 115200, 4800, 2400}
+
+// DetectBaudRate determines the baud rate of the device at the provided path by
+// requesting its firmware version at each of the CommonBaudRates, returning the
+// first one that yields a valid reply
+// NOTE: The port is opened (and closed again) once per attempt, hence no other
+// process must access the device during detection
+func DetectBaudRate(path string) (int, error) {
+
+	s := newSDS011(path)
+	s.open = s.openSerial
+	if err := s.detectBaudRate(); err != nil {
+		return 0, err
+	}
+	if err := s.Close(); err != nil {
+		return 0, fmt.Errorf("error closing %s after detecting baud rate %d: %w", path, s.baudRate, err)
+	}
+
+	return s.baudRate, nil
+}
+
+// detectBaudRate determines the baud rate of the device using the settings of
+// the sensor (e.g. framing and line settings), keeping the port open at the
+// detected baud rate
+func (s *SDS011) detectBaudRate() error {
+
+	for _, rate := range CommonBaudRates {
+		s.baudRate = rate
+		port, err := s.open()
+		if err != nil {
+			return err
+		}
+		s.conn = s.newConnection(port, s.minReadSize == 0)
+
+		if _, err := s.GetFirmwareTimeout(detectTimeout); err == nil {
+			return nil
+		}
+		if err := s.conn.close(); err != nil {
+			return fmt.Errorf("error closing %s after probing baud rate %d: %w", s.socket, rate, err)
+		}
+	}
+
+	return fmt.Errorf("failed to detect baud rate of %s, no valid reply at any of %v", s.socket, CommonBaudRates)
+}
@@ -0,0 +1,41 @@
+package sds011
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+func TestDetectBaudRate(t *testing.T) {
+
+	// Only the device reachable at 19200 baud replies
+	d, silent := newMockDevice(), newMockDevice()
+	silent.muted = true
+	defer d.close()
+	defer silent.close()
+
+	s := newSDS011("mock", WithBaudRate(0), WithTimeout(100*time.Millisecond))
+	s.open = func() (io.ReadWriteCloser, error) {
+		if s.baudRate == 19200 {
+			return d.open()
+		}
+		return silent.open()
+	}
+	defer s.Close() // #nosec G104
+
+	if err := s.detectBaudRate(); err != nil {
+		t.Fatalf("error detecting baud rate: %s", err)
+	}
+	if s.baudRate != 19200 {
+		t.Fatalf("unexpected baud rate, want 19200, have %d", s.baudRate)
+	}
+
+	// The port is kept open at the detected baud rate, using the options of the
+	// sensor
+	if _, err := s.GetFirmware(); err != nil {
+		t.Fatalf("error querying firmware after detection: %s", err)
+	}
+	if n := len(silent.commandTimes()); n != 1 {
+		t.Fatalf("unexpected number of commands sent at wrong baud rate, want 1, have %d", n)
+	}
+}
@@ -0,0 +1,148 @@
+package sds011
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Aggregation denotes a method to aggregate several data points into one
+type Aggregation int
+
+const (
+
+	// AggregationMean denotes the arithmetic mean of all values
+	AggregationMean Aggregation = iota
+
+	// AggregationMax denotes the maximum of all values
+	AggregationMax
+)
+
+// String returns the human-readable name of the aggregation, fulfilling the Stringer interface
+func (a Aggregation) String() string {
+	switch a {
+	case AggregationMean:
+		return "mean"
+	case AggregationMax:
+		return "max"
+	}
+
+	return "unknown"
+}
+
+// Bucketer denotes a downsampler that groups data points into aligned time buckets
+// (e.g. 5-minute intervals starting at the full hour) and aggregates each bucket
+// into a single data point once it is complete
+type Bucketer struct {
+	interval    time.Duration
+	aggregation Aggregation
+
+	start  time.Time
+	points []DataPoint
+	mutex  sync.Mutex
+}
+
+// NewBucketer creates a new Bucketer
+func NewBucketer(interval time.Duration, aggregation Aggregation) *Bucketer {
+	return &Bucketer{
+		interval:    interval,
+		aggregation: aggregation,
+	}
+}
+
+// Add ingests a data point. If it belongs to a later bucket than the current one,
+// the current bucket is closed and its aggregate is returned (with ok == true)
+// Data points are expected in chronological order, late data points are
+// attributed to the current bucket
+func (b *Bucketer) Add(p DataPoint) (bucket DataPoint, ok bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	start := p.TimeStamp.Truncate(b.interval)
+	if len(b.points) > 0 && start.After(b.start) {
+		bucket, ok = b.aggregate(), true
+		b.points = b.points[:0]
+	}
+	if len(b.points) == 0 {
+		b.start = start
+	}
+	b.points = append(b.points, p)
+
+	return
+}
+
+// Flush closes the current bucket (if any) and returns its aggregate
+func (b *Bucketer) Flush() (bucket DataPoint, ok bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if len(b.points) == 0 {
+		return DataPoint{}, false
+	}
+
+	bucket = b.aggregate()
+	b.points = b.points[:0]
+
+	return bucket, true
+}
+
+// aggregate computes the aggregate of the current bucket, carrying the bucket
+// start time and the metadata of the latest data point
+func (b *Bucketer) aggregate() DataPoint {
+	last := b.points[len(b.points)-1]
+	res := DataPoint{
+		TimeStamp: b.start,
+		DeviceID:  last.DeviceID,
+		Labels:    last.Labels,
+	}
+
+	if b.aggregation == AggregationMax {
+		res.PM25 = maxValue(b.points, func(p DataPoint) float64 { return p.PM25 })
+		res.PM10 = maxValue(b.points, func(p DataPoint) float64 { return p.PM10 })
+	} else {
+		res.PM25, res.PM10 = mean(b.points)
+	}
+
+	return res
+}
+
+// mean computes the mean PM2.5 / PM10 values of a set of data points (see Add()
+// and DivideScalar()), skipping NaN values (yielding NaN if no valid value exists)
+func mean(points []DataPoint) (pm25, pm10 float64) {
+
+	var (
+		sum      DataPoint
+		n25, n10 float64
+	)
+	for _, p := range points {
+		if math.IsNaN(p.PM25) {
+			p.PM25 = 0
+		} else {
+			n25++
+		}
+		if math.IsNaN(p.PM10) {
+			p.PM10 = 0
+		} else {
+			n10++
+		}
+		sum = sum.Add(p)
+	}
+
+	// Both fields may have a different number of valid values (dividing by zero
+	// yields NaN if there are none)
+	return sum.DivideScalar(n25).PM25, sum.DivideScalar(n10).PM10
+}
+
+// maxValue computes the maximum of a single field of a set of data points,
+// skipping NaN values (yielding NaN if no valid value exists)
+func maxValue(points []DataPoint, field func(DataPoint) float64) float64 {
+
+	res := math.NaN()
+	for _, p := range points {
+		if val := field(p); !math.IsNaN(val) && (math.IsNaN(res) || val > res) {
+			res = val
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,41 @@
+package sds011
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestBucketerAggregation(t *testing.T) {
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	points := []DataPoint{
+		{TimeStamp: start, PM25: 10, PM10: math.NaN()},
+		{TimeStamp: start.Add(time.Minute), PM25: math.NaN(), PM10: math.NaN()},
+		{TimeStamp: start.Add(2 * time.Minute), PM25: 20, PM10: math.NaN()},
+	}
+
+	for _, cs := range []struct {
+		aggregation Aggregation
+		pm25        float64
+	}{
+		{AggregationMean, 15},
+		{AggregationMax, 20},
+	} {
+		b := NewBucketer(5*time.Minute, cs.aggregation)
+		for _, p := range points {
+			if _, ok := b.Add(p); ok {
+				t.Fatalf("unexpected bucket closed by data point at %v", p.TimeStamp)
+			}
+		}
+
+		// NaN values are skipped, yielding NaN only if no valid value exists
+		bucket, ok := b.Flush()
+		if !ok {
+			t.Fatalf("no bucket flushed")
+		}
+		if bucket.PM25 != cs.pm25 || !math.IsNaN(bucket.PM10) || !bucket.TimeStamp.Equal(start) {
+			t.Fatalf("unexpected %s aggregate: %v", cs.aggregation, bucket)
+		}
+	}
+}
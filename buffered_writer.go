@@ -0,0 +1,289 @@
+package sds011
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrBufferFull denotes that a data point was rejected because the buffer of a
+// BufferedWriter is full
+var ErrBufferFull = errors.New("buffer full, data point rejected")
+
+// DropPolicy denotes the behavior of a BufferedWriter once its buffer is full
+// (i.e. if the underlying sink cannot keep up or is failing)
+type DropPolicy int
+
+const (
+
+	// DropPolicyReject rejects new data points with ErrBufferFull
+	DropPolicyReject DropPolicy = iota
+
+	// DropPolicyDropOldest silently discards the oldest buffered data point
+	DropPolicyDropOldest
+
+	// DropPolicyDropNewest silently discards the new data point
+	DropPolicyDropNewest
+)
+
+// BufferConfig denotes the configuration of a BufferedWriter
+type BufferConfig struct {
+
+	// BatchSize denotes the number of buffered data points triggering a flush
+	BatchSize int
+
+	// FlushInterval denotes the maximum time between flushes (0 disables
+	// time-based flushes)
+	FlushInterval time.Duration
+
+	// MaxPending denotes the maximum number of buffered data points (retained
+	// e.g. in case the underlying sink fails), must be at least BatchSize
+	MaxPending int
+
+	// Policy denotes the behavior once MaxPending data points are buffered
+	Policy DropPolicy
+}
+
+// DefaultBufferConfig denotes sane defaults for a BufferedWriter
+var DefaultBufferConfig = BufferConfig{
+	BatchSize:     100,
+	FlushInterval: 10 * time.Second,
+	MaxPending:    10000,
+	Policy:        DropPolicyDropOldest,
+}
+
+// BufferedWriter denotes a Sink that batches data points and writes them to an
+// underlying sink once a count or time threshold is reached
+// Writing to a BufferedWriter is safe for concurrent use (e.g. directly from
+// a reader goroutine), the underlying sink is written to without holding the
+// buffer lock (i.e. writes not triggering a flush never block on the sink)
+type BufferedWriter struct {
+	sink Sink
+	cfg  BufferConfig
+
+	pending   ring
+	unflushed int // Number of data points buffered since the last flush (attempt)
+	dropped   uint64
+	mutex     sync.Mutex
+
+	flushMutex sync.Mutex // Serializes writes to the underlying sink
+
+	done     chan struct{}
+	wg       sync.WaitGroup
+	isClosed bool
+}
+
+// NewBufferedWriter creates a new BufferedWriter wrapping the provided sink
+func NewBufferedWriter(sink Sink, cfg BufferConfig) (*BufferedWriter, error) {
+
+	if cfg.BatchSize < 1 {
+		return nil, fmt.Errorf("invalid batch size %d, must be at least 1", cfg.BatchSize)
+	}
+	if cfg.MaxPending < cfg.BatchSize {
+		return nil, fmt.Errorf("invalid maximum number of pending data points %d, must be at least the batch size (%d)", cfg.MaxPending, cfg.BatchSize)
+	}
+
+	w := &BufferedWriter{
+		sink:    sink,
+		cfg:     cfg,
+		pending: newRing(cfg.BatchSize, cfg.MaxPending),
+		done:    make(chan struct{}),
+	}
+
+	// Periodically flush the buffer, if requested
+	if cfg.FlushInterval > 0 {
+		w.wg.Add(1)
+		go w.flushLoop()
+	}
+
+	return w, nil
+}
+
+// Write buffers a single data point, flushing the buffer if the batch size is
+// reached
+// NOTE: If a flush fails, the retained data points are only retried once another
+// batch has been buffered (or on the next periodic / explicit flush)
+func (w *BufferedWriter) Write(p DataPoint) error {
+
+	flush, err := w.enqueue(p)
+	if err != nil || !flush {
+		return err
+	}
+
+	return w.flush()
+}
+
+// Flush writes all buffered data points to the underlying sink
+func (w *BufferedWriter) Flush() error {
+	return w.flush()
+}
+
+// Dropped returns the number of data points discarded due to a full buffer
+func (w *BufferedWriter) Dropped() uint64 {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.dropped
+}
+
+// Close drains the buffer and closes the underlying sink
+func (w *BufferedWriter) Close() error {
+	w.mutex.Lock()
+	if w.isClosed {
+		w.mutex.Unlock()
+		return nil
+	}
+	w.isClosed = true
+	close(w.done)
+	w.mutex.Unlock()
+
+	// Wait for the flush loop to terminate before draining the buffer
+	w.wg.Wait()
+
+	var errs MultiError
+	if err := w.flush(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := w.sink.Close(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errs.ErrorOrNil()
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// enqueue adds a data point to the buffer (applying the drop policy if it is
+// full), returning if a flush is due
+func (w *BufferedWriter) enqueue(p DataPoint) (bool, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.isClosed {
+		return false, fmt.Errorf("cannot write to closed buffered writer")
+	}
+
+	if w.pending.len >= w.cfg.MaxPending {
+		switch w.cfg.Policy {
+		case DropPolicyDropOldest:
+			w.pending.discard(w.pending.pos + 1)
+			w.dropped++
+		case DropPolicyDropNewest:
+			w.dropped++
+			return false, nil
+		default:
+			w.dropped++
+			return false, ErrBufferFull
+		}
+	}
+
+	w.pending.push(p)
+
+	w.unflushed++
+
+	return w.unflushed >= w.cfg.BatchSize, nil
+}
+
+func (w *BufferedWriter) flushLoop() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+
+			// Errors are retained in the buffer and surface on the next Write(),
+			// Flush() or Close() call
+			w.Flush() // #nosec G104
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// flush writes the buffer to the sink, retaining all data points that could
+// not be written
+// NOTE: The buffer is only locked to take a snapshot and to discard the written
+// data points, hence data points may be added (or dropped) while writing
+func (w *BufferedWriter) flush() error {
+	w.flushMutex.Lock()
+	defer w.flushMutex.Unlock()
+
+	w.mutex.Lock()
+	batch, pos := w.pending.slice(), w.pending.pos
+	w.unflushed = 0
+	w.mutex.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+	n, err := writeBatch(w.sink, batch)
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.pending.discard(pos + uint64(n))
+	if err != nil {
+		return fmt.Errorf("error flushing %d buffered data points: %w", len(batch)-n, err)
+	}
+
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// ring denotes a FIFO ring buffer of data points (growing on demand up to its
+// maximum capacity), addressing its elements by absolute position
+type ring struct {
+	points []DataPoint
+	start  int    // Index of the oldest element
+	len    int    // Number of elements
+	pos    uint64 // Absolute position of the oldest element
+	limit  int    // Maximum capacity
+}
+
+func newRing(capacity, limit int) ring {
+	return ring{
+		points: make([]DataPoint, capacity),
+		limit:  limit,
+	}
+}
+
+// push appends a data point (the ring must not be at its maximum capacity)
+func (r *ring) push(p DataPoint) {
+	if r.len == len(r.points) {
+		capacity := 2 * len(r.points)
+		if capacity > r.limit {
+			capacity = r.limit
+		}
+		points := r.slice()
+		r.points = append(points, make([]DataPoint, capacity-len(points))...)
+		r.start = 0
+	}
+
+	r.points[(r.start+r.len)%len(r.points)] = p
+	r.len++
+}
+
+// discard removes all data points prior to the provided absolute position
+func (r *ring) discard(pos uint64) {
+	for r.len > 0 && r.pos < pos {
+		r.points[r.start] = DataPoint{}
+		r.start = (r.start + 1) % len(r.points)
+		r.len--
+		r.pos++
+	}
+}
+
+// slice returns a copy of all data points (oldest to newest)
+func (r *ring) slice() []DataPoint {
+	points := make([]DataPoint, 0, r.len)
+	if end := r.start + r.len; end <= len(r.points) {
+		return append(points, r.points[r.start:end]...)
+	}
+
+	return append(append(points, r.points[r.start:]...), r.points[:(r.start+r.len)%len(r.points)]...)
+}
@@ -0,0 +1,126 @@
+package sds011
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+var errSinkUnavailable = errors.New("sink unavailable")
+
+// testSink records all data points written to it, optionally failing or
+// blocking until released
+type testSink struct {
+	points   []DataPoint
+	attempts int
+	fail     bool
+	release  chan struct{}
+	mutex    sync.Mutex
+}
+
+func (s *testSink) Write(p DataPoint) error {
+	if s.release != nil {
+		<-s.release
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.attempts++
+	if s.fail {
+		return errSinkUnavailable
+	}
+	s.points = append(s.points, p)
+
+	return nil
+}
+
+func (s *testSink) Close() error {
+	return nil
+}
+
+func TestBufferedWriterDropOldest(t *testing.T) {
+
+	sink := &testSink{fail: true}
+	w, err := NewBufferedWriter(sink, BufferConfig{
+		BatchSize:  2,
+		MaxPending: 5,
+		Policy:     DropPolicyDropOldest,
+	})
+	if err != nil {
+		t.Fatalf("error creating buffered writer: %s", err)
+	}
+
+	// A failed flush is only retried once another batch has been buffered
+	for i := 1; i <= 8; i++ {
+		err := w.Write(DataPoint{Seq: uint64(i)})
+		if (i%2 == 0) != errors.Is(err, errSinkUnavailable) {
+			t.Fatalf("unexpected error writing data point #%d: %v", i, err)
+		}
+	}
+	if sink.attempts != 4 {
+		t.Fatalf("unexpected number of flush attempts, want 4, have %d", sink.attempts)
+	}
+	if dropped := w.Dropped(); dropped != 3 {
+		t.Fatalf("unexpected number of dropped data points, want 3, have %d", dropped)
+	}
+
+	// Once the sink recovers, the latest data points are written in order
+	sink.fail = false
+	if err := w.Close(); err != nil {
+		t.Fatalf("error closing buffered writer: %s", err)
+	}
+	if len(sink.points) != 5 {
+		t.Fatalf("unexpected number of written data points, want 5, have %d", len(sink.points))
+	}
+	for i, p := range sink.points {
+		if p.Seq != uint64(i+4) {
+			t.Fatalf("unexpected data point #%d, want sequence number %d, have %d", i, i+4, p.Seq)
+		}
+	}
+}
+
+func TestBufferedWriterFlushDoesNotBlock(t *testing.T) {
+
+	sink := &testSink{release: make(chan struct{})}
+	w, err := NewBufferedWriter(sink, BufferConfig{
+		BatchSize:  1,
+		MaxPending: 10,
+		Policy:     DropPolicyReject,
+	})
+	if err != nil {
+		t.Fatalf("error creating buffered writer: %s", err)
+	}
+
+	// The first write triggers a flush blocking on the sink
+	done := make(chan error)
+	go func() {
+		done <- w.Write(DataPoint{Seq: 1})
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	// Buffering and retrieving metrics must not be blocked in the meantime
+	writeDone := make(chan struct{})
+	go func() {
+		defer close(writeDone)
+		w.enqueue(DataPoint{Seq: 2}) // #nosec G104
+		w.Dropped()
+	}()
+	select {
+	case <-writeDone:
+	case <-time.After(time.Second):
+		t.Fatalf("buffer blocked by pending flush")
+	}
+
+	close(sink.release)
+	if err := <-done; err != nil {
+		t.Fatalf("error writing data point: %s", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("error closing buffered writer: %s", err)
+	}
+	if len(sink.points) != 2 {
+		t.Fatalf("unexpected number of written data points, want 2, have %d", len(sink.points))
+	}
+}
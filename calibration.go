@@ -0,0 +1,27 @@
+package sds011
+
+// Calibration denotes a linear correction (factor * value + offset) applied to
+// the raw PM2.5 / PM10 values of a sensor
+type Calibration struct {
+	PM25Factor float64 `json:"pm25_factor"`
+	PM25Offset float64 `json:"pm25_offset"`
+	PM10Factor float64 `json:"pm10_factor"`
+	PM10Offset float64 `json:"pm10_offset"`
+}
+
+// DefaultCalibration denotes the identity calibration (no correction)
+var DefaultCalibration = Calibration{
+	PM25Factor: 1.,
+	PM10Factor: 1.,
+}
+
+// Apply returns a copy of the data point with the calibration applied
+func (c Calibration) Apply(p DataPoint) DataPoint {
+	p.PM25, p.PM10 = c.apply(p.PM25, p.PM10)
+
+	return p
+}
+
+func (c Calibration) apply(pm25, pm10 float64) (float64, float64) {
+	return c.PM25Factor*pm25 + c.PM25Offset, c.PM10Factor*pm10 + c.PM10Offset
+}
@@ -0,0 +1,140 @@
+package sds011
+
+import "fmt"
+
+// Command denotes a (sub-)command of the SDS011 protocol
+type Command byte
+
+const (
+
+	// CommandReportingMode denotes the command to get / set the reporting mode
+	CommandReportingMode = Command(0x02)
+
+	// CommandQueryData denotes the command to query data (answered with a data
+	// packet instead of a reply packet)
+	CommandQueryData = Command(0x04)
+
+	// CommandDeviceID denotes the command to set the device ID
+	CommandDeviceID = Command(0x05)
+
+	// CommandSleepWork denotes the command to get / set the work mode
+	CommandSleepWork = Command(0x06)
+
+	// CommandFirmware denotes the command to get the firmware version
+	CommandFirmware = Command(0x07)
+
+	// CommandWorkingPeriod denotes the command to get / set the working period
+	CommandWorkingPeriod = Command(0x08)
+)
+
+const (
+	commandHeader = 0xb4
+
+	// commandLen denotes the length of a command packet, commandDataLen the
+	// number of data bytes it carries
+	commandLen     = 19
+	commandDataLen = 12
+
+	// commandGet / commandSet denote the first data byte of configuration
+	// commands, distinguishing between reading and changing a setting
+	commandGet = 0x00
+	commandSet = 0x01
+)
+
+// String returns the human-readable name of the command, fulfilling the Stringer interface
+func (c Command) String() string {
+	switch c {
+	case CommandReportingMode:
+		return "reporting mode"
+	case CommandQueryData:
+		return "query data"
+	case CommandDeviceID:
+		return "device ID"
+	case CommandSleepWork:
+		return "sleep / work"
+	case CommandFirmware:
+		return "firmware"
+	case CommandWorkingPeriod:
+		return "working period"
+	}
+
+	return fmt.Sprintf("unknown (%02x)", byte(c))
+}
+
+// payloadLengths denotes the expected number of data bytes per command (get /
+// set commands are distinguished via their first data byte, see ValidatePayload())
+//
+//	Command          Get  Set
+//	reporting mode     1    2  (get / set, mode)
+//	query data         0    -
+//	device ID          -   12  (10 reserved bytes, new ID)
+//	sleep / work       1    2  (get / set, mode)
+//	firmware           0    -
+//	working period     1    2  (get / set, delay in minutes)
+var payloadLengths = map[Command]struct {
+	get, set int
+}{
+	CommandReportingMode: {get: 1, set: 2},
+	CommandQueryData:     {get: 0, set: -1},
+	CommandDeviceID:      {get: -1, set: commandDataLen},
+	CommandSleepWork:     {get: 1, set: 2},
+	CommandFirmware:      {get: 0, set: -1},
+	CommandWorkingPeriod: {get: 1, set: 2},
+}
+
+// ValidatePayload ensures that the data bytes of a command match the length
+// expected for the command (see payloadLengths), since the device silently
+// ignores malformed commands. Unknown commands are only checked against the
+// maximum number of data bytes (12).
+func ValidatePayload(cmd Command, payload []byte) error {
+	if len(payload) > commandDataLen {
+		return fmt.Errorf("too many data bytes for command %s, want at most %d, have %d", cmd, commandDataLen, len(payload))
+	}
+
+	lengths, known := payloadLengths[cmd]
+	if !known {
+		return nil
+	}
+
+	// Configuration commands are distinguished by their first data byte
+	want := lengths.get
+	switch {
+	case lengths.get < 0:
+		want = lengths.set
+	case lengths.set < 0:
+	case len(payload) > 0 && payload[0] == commandSet:
+		want = lengths.set
+	}
+	if len(payload) != want {
+		return fmt.Errorf("unexpected number of data bytes for command %s, want %d, have %d", cmd, want, len(payload))
+	}
+
+	return nil
+}
+
+// BuildCommand creates a command packet addressed to all devices, carrying the
+// provided data bytes (zero-padded, see ValidatePayload())
+func BuildCommand(cmd Command, data ...byte) ([]byte, error) {
+	return BuildCommandFor(DeviceIDAll, cmd, data...)
+}
+
+// BuildCommandFor creates a command packet addressed to a specific device,
+// carrying the provided data bytes (zero-padded, see ValidatePayload())
+func BuildCommandFor(id DeviceID, cmd Command, data ...byte) ([]byte, error) {
+	return buildCommand(defaultFraming, id, cmd, data...)
+}
+
+func buildCommand(fr framing, id DeviceID, cmd Command, data ...byte) ([]byte, error) {
+	if err := ValidatePayload(cmd, data); err != nil {
+		return nil, err
+	}
+
+	txData := make([]byte, commandLen)
+	txData[0], txData[1], txData[2] = fr.header, commandHeader, byte(cmd)
+	copy(txData[3:], data)
+	txData[15], txData[16] = byte(id>>8), byte(id)
+	txData[17] = calcChecksum(txData[2:17])
+	txData[18] = fr.tail
+
+	return txData, nil
+}
@@ -0,0 +1,196 @@
+package sds011
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Config denotes a reusable set of settings for an application reading from
+// an SDS011 sensor on a regular basis (see LoopConfig())
+// NOTE: Configurations can only be read from JSON files, YAML is not supported
+// (see LoadConfig())
+type Config struct {
+	DevicePath       string
+	SpinUp           time.Duration
+	MeasurementDelay time.Duration
+	ServerEndpoint   string
+	WorkPeriod       int
+	Calibration      Calibration
+
+	// ErrorRepeatInterval denotes the interval in which identical consecutive
+	// errors are reported (see LoopConfig)
+	ErrorRepeatInterval time.Duration
+
+	// ModeCheckInterval denotes the interval in which the modes of the device
+	// are checked for drift (0 disables the check, see LoopConfig)
+	ModeCheckInterval time.Duration
+}
+
+// DefaultConfig returns a configuration populated with sane defaults
+func DefaultConfig() *Config {
+	return &Config{
+		DevicePath:       "/dev/ttyUSB0",
+		SpinUp:           30 * time.Second,
+		MeasurementDelay: 5 * time.Minute,
+		ServerEndpoint:   "0.0.0.0:8000",
+		WorkPeriod:       WorkPeriodContinuous,
+		Calibration:      DefaultCalibration,
+
+		ErrorRepeatInterval: 10 * time.Minute,
+	}
+}
+
+// LoadConfig reads a configuration from a JSON file, using the defaults for
+// all settings not present in the file
+// NOTE: Only JSON is supported in order to keep the package free of external
+// dependencies, durations are expected in string form (e.g. "30s" or "5m")
+func LoadConfig(path string) (*Config, error) {
+
+	if ext := strings.ToLower(filepath.Ext(path)); ext != ".json" && ext != "" {
+		return nil, fmt.Errorf("unsupported config file format `%s`, only JSON is supported", ext)
+	}
+
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("error reading config file %s: %w", path, err)
+	}
+
+	cfg := DefaultConfig()
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("error parsing config file %s: %w", path, err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// LoopConfig returns a configuration for a measurement loop on the device (see
+// RunLoop()), applying all respective settings
+// NOTE: The calibration is not part of the loop configuration, it has to be
+// applied when opening the sensor (see WithCalibration())
+func (c *Config) LoopConfig() LoopConfig {
+	cfg := DefaultLoopConfig(c.DevicePath)
+	cfg.SpinUp = c.SpinUp
+	cfg.MeasurementDelay = c.MeasurementDelay
+	cfg.WorkPeriod = c.WorkPeriod
+	cfg.ErrorRepeatInterval = c.ErrorRepeatInterval
+	cfg.ModeCheckInterval = c.ModeCheckInterval
+
+	return cfg
+}
+
+// Validate checks if all settings of the configuration are within their limits
+func (c *Config) Validate() error {
+	if c.DevicePath == "" {
+		return fmt.Errorf("no device path specified")
+	}
+	if c.SpinUp < 0 {
+		return fmt.Errorf("spin-up duration must not be negative, have %v", c.SpinUp)
+	}
+	if c.MeasurementDelay <= 0 {
+		return fmt.Errorf("measurement delay must be positive, have %v", c.MeasurementDelay)
+	}
+	if c.WorkPeriod < WorkPeriodContinuous || c.WorkPeriod > WorkPeriodMax {
+		return fmt.Errorf("work period out of limits, must be between 0 and 30 (minutes), have %d", c.WorkPeriod)
+	}
+	if c.ErrorRepeatInterval < 0 {
+		return fmt.Errorf("error repeat interval must not be negative, have %v", c.ErrorRepeatInterval)
+	}
+	if c.ModeCheckInterval < 0 {
+		return fmt.Errorf("mode check interval must not be negative, have %v", c.ModeCheckInterval)
+	}
+
+	return nil
+}
+
+type configJSON struct {
+	DevicePath       string       `json:"device_path"`
+	SpinUp           string       `json:"spin_up"`
+	MeasurementDelay string       `json:"measurement_delay"`
+	ServerEndpoint   string       `json:"server_endpoint"`
+	WorkPeriod       *int         `json:"work_period"`
+	Calibration      *Calibration `json:"calibration"`
+
+	ErrorRepeatInterval string `json:"error_repeat_interval"`
+	ModeCheckInterval   string `json:"mode_check_interval"`
+}
+
+// UnmarshalJSON parses a JSON representation of the configuration, only
+// overwriting settings that are present, fulfilling the json.Unmarshaler interface
+func (c *Config) UnmarshalJSON(data []byte) error {
+
+	calibration := c.Calibration
+	raw := configJSON{
+		Calibration: &calibration,
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	if raw.DevicePath != "" {
+		c.DevicePath = raw.DevicePath
+	}
+	if raw.ServerEndpoint != "" {
+		c.ServerEndpoint = raw.ServerEndpoint
+	}
+	if raw.SpinUp != "" {
+		d, err := time.ParseDuration(raw.SpinUp)
+		if err != nil {
+			return fmt.Errorf("error parsing spin_up: %w", err)
+		}
+		c.SpinUp = d
+	}
+	if raw.MeasurementDelay != "" {
+		d, err := time.ParseDuration(raw.MeasurementDelay)
+		if err != nil {
+			return fmt.Errorf("error parsing measurement_delay: %w", err)
+		}
+		c.MeasurementDelay = d
+	}
+	if raw.ErrorRepeatInterval != "" {
+		d, err := time.ParseDuration(raw.ErrorRepeatInterval)
+		if err != nil {
+			return fmt.Errorf("error parsing error_repeat_interval: %w", err)
+		}
+		c.ErrorRepeatInterval = d
+	}
+	if raw.ModeCheckInterval != "" {
+		d, err := time.ParseDuration(raw.ModeCheckInterval)
+		if err != nil {
+			return fmt.Errorf("error parsing mode_check_interval: %w", err)
+		}
+		c.ModeCheckInterval = d
+	}
+	if raw.WorkPeriod != nil {
+		c.WorkPeriod = *raw.WorkPeriod
+	}
+	if raw.Calibration != nil {
+		c.Calibration = *raw.Calibration
+	}
+
+	return nil
+}
+
+// MarshalJSON returns a JSON representation of the configuration, fulfilling
+// the json.Marshaler interface
+func (c Config) MarshalJSON() ([]byte, error) {
+	workPeriod, calibration := c.WorkPeriod, c.Calibration
+	return json.Marshal(configJSON{
+		DevicePath:       c.DevicePath,
+		SpinUp:           c.SpinUp.String(),
+		MeasurementDelay: c.MeasurementDelay.String(),
+		ServerEndpoint:   c.ServerEndpoint,
+		WorkPeriod:       &workPeriod,
+		Calibration:      &calibration,
+
+		ErrorRepeatInterval: c.ErrorRepeatInterval.String(),
+		ModeCheckInterval:   c.ModeCheckInterval.String(),
+	})
+}
@@ -0,0 +1,36 @@
+package sds011
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"device_path": "/dev/ttyUSB1", "measurement_delay": "10m", "work_period": 5}`), 0600); err != nil {
+		t.Fatalf("error writing config file: %s", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("error loading config: %s", err)
+	}
+
+	// All settings must be propagated to the loop configuration (settings not
+	// present in the file falling back to their defaults)
+	loopCfg := cfg.LoopConfig()
+	if loopCfg.MeasurementDelay != 10*time.Minute || loopCfg.WorkPeriod != 5 ||
+		loopCfg.SpinUp != DefaultConfig().SpinUp || loopCfg.ErrorRepeatInterval != DefaultConfig().ErrorRepeatInterval {
+		t.Fatalf("unexpected loop configuration: %+v", loopCfg)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"work_period": 31}`), 0600); err != nil {
+		t.Fatalf("error writing config file: %s", err)
+	}
+	if _, err := LoadConfig(path); err == nil {
+		t.Fatalf("expected error loading config with out-of-range work period")
+	}
+}
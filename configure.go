@@ -0,0 +1,99 @@
+package sds011
+
+import "fmt"
+
+// DesiredConfig denotes a desired configuration of the device, consisting of
+// optional settings (nil fields are left untouched, see Configure())
+type DesiredConfig struct {
+	WorkMode      *WorkMode
+	ReportingMode *ReportingMode
+	WorkPeriod    *int
+	DeviceID      *DeviceID
+}
+
+// Configure applies a desired configuration to the device (e.g. at startup): All
+// settings provided are applied and verified by reading them back (the work mode
+// last, such that all other settings are applied before the device is put to sleep,
+// if requested). Failing settings do not prevent the remaining ones from being
+// applied, all failures are returned as MultiError.
+// NOTE: A sleeping device only replies to work mode changes, hence the device
+// has to be awake if any other setting is provided
+func (s *SDS011) Configure(cfg DesiredConfig) error {
+
+	var errs MultiError
+	if cfg.DeviceID != nil {
+		if err := s.SetDeviceID(*cfg.DeviceID); err != nil {
+			errs = append(errs, fmt.Errorf("error setting device ID: %w", err))
+		}
+	}
+	if cfg.ReportingMode != nil {
+		if err := s.verifyReportingMode(*cfg.ReportingMode); err != nil {
+			errs = append(errs, fmt.Errorf("error setting reporting mode: %w", err))
+		}
+	}
+	if cfg.WorkPeriod != nil {
+		if err := s.verifyWorkPeriod(*cfg.WorkPeriod); err != nil {
+			errs = append(errs, fmt.Errorf("error setting work period: %w", err))
+		}
+	}
+	if cfg.WorkMode != nil {
+		if err := s.verifyWorkMode(*cfg.WorkMode); err != nil {
+			errs = append(errs, fmt.Errorf("error setting work mode: %w", err))
+		}
+	}
+
+	return errs.ErrorOrNil()
+}
+
+// verifyWorkMode sets the work mode of the device and reads it back (unless the
+// device is put to sleep, in which case it does not reply to queries and only the
+// confirmation of the command itself is verified, see setWorkMode())
+func (s *SDS011) verifyWorkMode(mode WorkMode) error {
+	if err := s.SetWorkMode(mode); err != nil {
+		return err
+	}
+	if mode == WorkModeSleep {
+		return nil
+	}
+	confirmed, err := s.RefreshWorkMode()
+	if err != nil {
+		return err
+	}
+	if confirmed != mode {
+		return fmt.Errorf("unexpected work mode, want %s, have %s", mode, confirmed)
+	}
+
+	return nil
+}
+
+// verifyReportingMode sets the reporting mode of the device and reads it back
+func (s *SDS011) verifyReportingMode(mode ReportingMode) error {
+	if err := s.SetReportingMode(mode); err != nil {
+		return err
+	}
+	confirmed, err := s.RefreshReportingMode()
+	if err != nil {
+		return err
+	}
+	if confirmed != mode {
+		return fmt.Errorf("unexpected reporting mode, want %s, have %s", mode, confirmed)
+	}
+
+	return nil
+}
+
+// verifyWorkPeriod sets the working period of the device and reads it back
+func (s *SDS011) verifyWorkPeriod(delayMinutes int) error {
+	if err := s.SetWorkPeriod(delayMinutes); err != nil {
+		return err
+	}
+	confirmed, err := s.GetWorkPeriod()
+	if err != nil {
+		return err
+	}
+	if confirmed != delayMinutes {
+		return fmt.Errorf("unexpected work period, want %d, have %d", delayMinutes, confirmed)
+	}
+
+	return nil
+}
@@ -0,0 +1,28 @@
+package sds011
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConfigureSleep(t *testing.T) {
+
+	d := newMockDevice()
+	d.activeReports = true
+	s := newMockSensor(t, d, WithTimeout(100*time.Millisecond))
+
+	// Putting the device to sleep must not attempt to read back its work mode
+	workMode, reportingMode := WorkModeSleep, ReportingModeQuery
+	if err := s.Configure(DesiredConfig{
+		WorkMode:      &workMode,
+		ReportingMode: &reportingMode,
+	}); err != nil {
+		t.Fatalf("error configuring device: %s", err)
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.awake || d.activeReports {
+		t.Fatalf("unexpected state of device (awake: %v, active reports: %v)", d.awake, d.activeReports)
+	}
+}
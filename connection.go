@@ -0,0 +1,298 @@
+package sds011
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"time"
+)
+
+// ErrClosed denotes that the sensor was closed explicitly
+var ErrClosed = errors.New("sensor is closed")
+
+const (
+
+	// DefaultDialTimeout denotes the default timeout for establishing a TCP connection
+	DefaultDialTimeout = 10 * time.Second
+
+	// maxFrameSize denotes the maximum number of bytes discarded while waiting
+	// for the header of a frame before passing them on as (invalid) frame
+	maxFrameSize = 64
+
+	// flushTimeout denotes the time to wait for stale data on a network connection
+	flushTimeout = 50 * time.Millisecond
+)
+
+// NewTCP creates a new SDS011 object for a device bridged to the network (e.g.
+// via ser2net or a WiFi serial bridge), connecting to the provided TCP address
+// The serial line settings (9600 8N1) have to be configured on the bridge
+// NOTE: TCP keepalive is enabled (see WithTCPKeepAlive()), hence a silently
+// dropped bridge surfaces as connection error (triggering a reconnect in Stream()
+// / RunLoop()) instead of a sequence of timeouts
+func NewTCP(addr string, opts ...Option) (*SDS011, error) {
+
+	s := newSDS011(addr, opts...)
+
+	dialer := net.Dialer{
+		Timeout:   DefaultDialTimeout,
+		KeepAlive: s.keepAliveInterval,
+	}
+	s.open = func() (io.ReadWriteCloser, error) {
+		conn, err := dialer.Dial("tcp", addr)
+		if err != nil {
+			return nil, fmt.Errorf("error connecting to %s: %w", addr, err)
+		}
+		if tcpConn, ok := conn.(*net.TCPConn); ok && s.keepAliveCount > 0 {
+			if err := setKeepAliveCount(tcpConn, s.keepAliveCount); err != nil {
+				conn.Close() // #nosec G104
+				return nil, fmt.Errorf("error configuring keepalive on connection to %s: %w", addr, err)
+			}
+		}
+		return conn, nil
+	}
+	port, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+	s.conn = s.newConnection(port, false)
+
+	return s, nil
+}
+
+// Reconnect closes the current connection to the device (ignoring any error)
+// and reopens it, invalidating any cached device state and discarding stale
+// input pending on the new connection
+func (s *SDS011) Reconnect() error {
+	s.portMutex.Lock()
+	defer s.portMutex.Unlock()
+
+	if s.isClosed {
+		return ErrClosed
+	}
+
+	s.conn.close() // #nosec G104
+	s.invalidateModeCache()
+
+	port, err := s.open()
+	if err != nil {
+		return fmt.Errorf("error reconnecting: %w", err)
+	}
+	s.conn = s.newConnection(port, s.conn.ignoreEOF)
+	s.metrics.add(func(m *Metrics) { m.Reconnects++ })
+
+	return nil
+}
+
+// ConnState denotes the state of the connection to the device
+type ConnState int
+
+const (
+
+	// ConnStateConnected denotes a usable connection
+	ConnStateConnected ConnState = iota
+
+	// ConnStateDisconnected denotes a broken connection (e.g. after an I/O error
+	// or a failed reconnect attempt), which may be recovered via Reconnect()
+	ConnStateDisconnected
+
+	// ConnStateClosed denotes that the sensor was closed
+	ConnStateClosed
+)
+
+// String returns the human-readable name of the connection state, fulfilling the Stringer interface
+func (c ConnState) String() string {
+	switch c {
+	case ConnStateConnected:
+		return "connected"
+	case ConnStateDisconnected:
+		return "disconnected"
+	}
+
+	return "closed"
+}
+
+// State returns the current state of the connection to the device
+func (s *SDS011) State() ConnState {
+	s.portMutex.Lock()
+	defer s.portMutex.Unlock()
+
+	if s.isClosed {
+		return ConnStateClosed
+	}
+	if s.conn.isClosed() || s.conn.isStopped() {
+		return ConnStateDisconnected
+	}
+
+	return ConnStateConnected
+}
+
+// getConn returns the current connection
+func (s *SDS011) getConn() *connection {
+	s.portMutex.Lock()
+	defer s.portMutex.Unlock()
+
+	return s.conn
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// connection denotes an open port to the device and a background reader that
+// continuously assembles frames from it (ensuring that no data is lost between
+// reads, regardless of how many bytes a single read returns)
+type connection struct {
+	port      io.ReadWriteCloser
+	ignoreEOF bool
+	header    byte
+
+	frames  chan []byte
+	done    chan struct{}
+	stopped chan struct{} // Closed once the reader has terminated
+	err     error         // Error that terminated the reader (valid once frames is closed)
+}
+
+// newConnection wraps a port and starts its background reader, discarding any
+// stale data pending on the port (e.g. left over from a previous connection)
+// If ignoreEOF is set, empty reads (reported as io.EOF by serial ports configured
+// with a read timeout) are not considered to be an error. Serial ports are
+// wrapped such that closing the connection terminates the background reader
+// (see wrapFile()).
+func (s *SDS011) newConnection(port io.ReadWriteCloser, ignoreEOF bool) *connection {
+	flushInput(port)
+	if f, ok := port.(*os.File); ok {
+		port = wrapFile(f)
+	}
+
+	c := &connection{
+		port:      port,
+		ignoreEOF: ignoreEOF,
+		header:    s.framing.header,
+		frames:    make(chan []byte, 1),
+		done:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+	go c.readLoop(s.trace)
+
+	return c
+}
+
+// readLoop continuously reads frames from the port until an error occurs or
+// the connection is closed. Frames are assembled by synchronizing on the header
+// byte and reading the fixed length expected for the kind of packet (see
+// frameLength()), hence payload bytes matching the tail byte do not split a frame
+func (c *connection) readLoop(trace TraceFunc) {
+	defer close(c.stopped)
+	defer close(c.frames)
+
+	reader := bufio.NewReader(c.port)
+
+	var frame, junk []byte
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			if c.ignoreEOF && errors.Is(err, io.EOF) && !c.isClosed() {
+				continue
+			}
+			c.err = err
+			return
+		}
+
+		// Discard any data preceding the header byte (once the maximum frame size
+		// is exceeded, the data is passed on as is, yielding a framing error on
+		// validation)
+		if len(frame) == 0 && b != c.header {
+			if junk = append(junk, b); len(junk) < maxFrameSize {
+				continue
+			}
+			frame, junk = junk, nil
+		} else {
+			junk, frame = nil, append(frame, b)
+			if len(frame) < 2 || len(frame) < frameLength(PacketKind(frame[1])) {
+				continue
+			}
+		}
+
+		if trace != nil {
+			trace(TraceRX, frame)
+		}
+
+		select {
+		case c.frames <- frame:
+		case <-c.done:
+			return
+		}
+		frame = nil
+	}
+}
+
+// flushInput discards all data pending on a port before it is used (best effort)
+func flushInput(port io.ReadWriteCloser) {
+	switch p := port.(type) {
+	case *os.File:
+		flushFile(p)
+	case net.Conn:
+
+		// Discard everything that arrives before the deadline, then reset it
+		if err := p.SetReadDeadline(time.Now().Add(flushTimeout)); err != nil {
+			return
+		}
+		io.Copy(io.Discard, p)         // #nosec G104
+		p.SetReadDeadline(time.Time{}) // #nosec G104
+	}
+}
+
+// drain discards any frames that were received but not consumed yet (e.g. stale
+// replies received after a timeout)
+func (c *connection) drain() {
+	for {
+		select {
+		case _, ok := <-c.frames:
+			if !ok {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (c *connection) isClosed() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *connection) isStopped() bool {
+	select {
+	case <-c.stopped:
+		return true
+	default:
+		return false
+	}
+}
+
+// close closes the port and terminates the background reader
+func (c *connection) close() error {
+	if !c.isClosed() {
+		close(c.done)
+	}
+
+	return c.port.Close()
+}
+
+// isConnectionError determines if an error indicates that the connection to the
+// device is no longer usable (e.g. the port vanished or a network connection broke)
+func isConnectionError(err error) bool {
+	var opErr *net.OpError
+	return errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.ErrClosedPipe) ||
+		errors.Is(err, net.ErrClosed) ||
+		errors.Is(err, os.ErrClosed) ||
+		errors.As(err, &opErr)
+}
@@ -0,0 +1,164 @@
+package sds011
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jacobsa/go-serial/serial"
+	"golang.org/x/sys/unix"
+)
+
+// openPTY opens a pseudo terminal, returning its master end and the path of
+// its slave end (serving as serial port)
+func openPTY(t testing.TB) (*os.File, string) {
+	t.Helper()
+
+	fd, err := unix.Open("/dev/ptmx", unix.O_RDWR|unix.O_NOCTTY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		t.Skipf("pseudo terminals not available: %s", err)
+	}
+	master := os.NewFile(uintptr(fd), "/dev/ptmx")
+	t.Cleanup(func() {
+		master.Close() // #nosec G104
+	})
+
+	if err := unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0); err != nil {
+		t.Fatalf("error unlocking pseudo terminal: %s", err)
+	}
+	n, err := unix.IoctlGetInt(fd, unix.TIOCGPTN)
+	if err != nil {
+		t.Fatalf("error determining pseudo terminal: %s", err)
+	}
+
+	return master, fmt.Sprintf("/dev/pts/%d", n)
+}
+
+func TestReconnectTerminatesSerialReader(t *testing.T) {
+
+	master, path := openPTY(t)
+	s, err := New(path, WithTimeout(time.Second))
+	if err != nil {
+		t.Fatalf("error opening %s: %s", path, err)
+	}
+	defer s.Close()
+
+	for i := 0; i < 5; i++ {
+		old := s.getConn()
+
+		reconnected := make(chan error)
+		go func() {
+			reconnected <- s.Reconnect()
+		}()
+		select {
+		case err := <-reconnected:
+			if err != nil {
+				t.Fatalf("error reconnecting: %s", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("reconnect blocked by pending read of previous connection")
+		}
+
+		// The reader of the previous connection must terminate (instead of
+		// consuming data destined for the new connection)
+		select {
+		case <-old.stopped:
+		case <-time.After(time.Second):
+			t.Fatalf("reader of previous connection still running after reconnect")
+		}
+
+		if _, err := master.Write(mockDataFrame(100, 200)); err != nil {
+			t.Fatalf("error writing data frame: %s", err)
+		}
+		dataPoint, err := s.WaitForData()
+		if err != nil {
+			t.Fatalf("error reading first data frame after reconnect: %s", err)
+		}
+		if dataPoint.PM25 != 10 || dataPoint.PM10 != 20 {
+			t.Fatalf("unexpected data point, want 10 / 20, have %v / %v", dataPoint.PM25, dataPoint.PM10)
+		}
+	}
+}
+
+func TestCloseTerminatesSerialReader(t *testing.T) {
+
+	_, path := openPTY(t)
+	s, err := New(path, WithTimeout(time.Second))
+	if err != nil {
+		t.Fatalf("error opening %s: %s", path, err)
+	}
+
+	conn := s.getConn()
+	if err := s.Close(); err != nil {
+		t.Fatalf("error closing sensor: %s", err)
+	}
+	select {
+	case <-conn.stopped:
+	case <-time.After(time.Second):
+		t.Fatalf("reader still running after close")
+	}
+}
+
+// countingPort counts the number of (non-empty) reads from a port
+type countingPort struct {
+	io.ReadWriteCloser
+	reads int64
+}
+
+func (p *countingPort) Read(b []byte) (int, error) {
+	n, err := p.ReadWriteCloser.Read(b)
+	if n > 0 {
+		atomic.AddInt64(&p.reads, 1)
+	}
+	return n, err
+}
+
+func BenchmarkMinimumReadSize(b *testing.B) {
+
+	for _, size := range []uint{1, 5, dataFrameLen} {
+		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
+
+			master, path := openPTY(b)
+			port, err := serial.Open(serial.OpenOptions{
+				PortName:              path,
+				BaudRate:              DefaultBaudRate,
+				DataBits:              8,
+				StopBits:              1,
+				MinimumReadSize:       size,
+				InterCharacterTimeout: 100,
+			})
+			if err != nil {
+				b.Fatalf("error opening %s: %s", path, err)
+			}
+			counter := &countingPort{ReadWriteCloser: wrapFile(port.(*os.File))}
+			s := newSDS011(path)
+			s.conn = s.newConnection(counter, false)
+			defer s.Close()
+
+			// Emit each frame byte by byte (as received on a serial line)
+			frame := mockDataFrame(123, 456)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for j := range frame {
+					if _, err := master.Write(frame[j : j+1]); err != nil {
+						b.Fatalf("error writing frame: %s", err)
+					}
+				}
+				rxData, err := s.readRawData(context.Background(), time.Second)
+				if err != nil {
+					b.Fatalf("error reading frame: %s", err)
+				}
+				if err := validateRxData(rxData); err != nil {
+					b.Fatalf("invalid frame assembled: %s", err)
+				}
+			}
+			b.StopTimer()
+
+			b.ReportMetric(float64(atomic.LoadInt64(&counter.reads))/float64(b.N), "reads/frame")
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package sds011
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestReconnectDiscardsStaleInput(t *testing.T) {
+
+	// Inject garbage on each new connection (emulating stale data in the driver
+	// buffer when the port is opened)
+	garbage := []byte{0x12, packetTail, packetHeader, 0xc0, 0xff, 0x00, packetTail, 0x42}
+	d := newMockDevice()
+	d.onConnect = func(w io.Writer) {
+		w.Write(garbage) // #nosec G104
+	}
+	s := newMockSensor(t, d)
+
+	// Inject garbage on the current connection right before reconnecting
+	d.mutex.Lock()
+	conn := d.conns[0]
+	d.mutex.Unlock()
+	if err := d.write(conn, garbage); err != nil {
+		t.Fatalf("error injecting garbage: %s", err)
+	}
+
+	if err := s.Reconnect(); err != nil {
+		t.Fatalf("error reconnecting: %s", err)
+	}
+
+	// The first read after reconnecting must be clean
+	if _, err := s.GetFirmware(); err != nil {
+		t.Fatalf("first read after reconnect failed: %s", err)
+	}
+	if m := s.Metrics(); m.ChecksumFailures != 0 {
+		t.Fatalf("unexpected number of checksum failures, want 0, have %d", m.ChecksumFailures)
+	}
+}
+
+func TestEndlessStreamIsBounded(t *testing.T) {
+
+	// Emit an endless stream of bytes without any packet header
+	d := newMockDevice()
+	d.onConnect = func(w io.Writer) {
+		junk := bytes.Repeat([]byte{0x42}, 7)
+		for {
+			if _, err := w.Write(junk); err != nil {
+				return
+			}
+		}
+	}
+	s := newMockSensor(t, d)
+
+	// Each frame passed on is bounded by the maximum frame size
+	for i := 0; i < 10; i++ {
+		frame, err := s.readRawData(context.Background(), time.Second)
+		if err != nil {
+			t.Fatalf("error reading from endless stream: %s", err)
+		}
+		if len(frame) > maxFrameSize {
+			t.Fatalf("frame exceeds maximum frame size, want at most %d, have %d", maxFrameSize, len(frame))
+		}
+	}
+
+	// A read of a data point fails promptly with a framing error (instead of
+	// blocking until the timeout)
+	start := time.Now()
+	if _, err := s.WaitForDataTimeout(5 * time.Second); !errors.Is(err, ErrInvalidFrame) {
+		t.Fatalf("unexpected error, want %v, have %v", ErrInvalidFrame, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("read took %v, want less than a second", elapsed)
+	}
+}
+
+func TestFrameAfterGarbage(t *testing.T) {
+
+	// Emit garbage (including tail bytes) before each reply, both below and above
+	// the maximum frame size
+	for _, n := range []int{3, maxFrameSize - 1, 3 * maxFrameSize} {
+		d := newMockDevice()
+		s := newMockSensor(t, d)
+
+		d.mutex.Lock()
+		conn := d.conns[0]
+		d.mutex.Unlock()
+		garbage := bytes.Repeat([]byte{0x42, packetTail}, n)[:n]
+		go d.write(conn, garbage) // #nosec G104
+
+		// The garbage is discarded up to the next header, such that the reply
+		// following it is received (either directly or after the framing errors
+		// caused by oversized garbage)
+		var err error
+		for i := 0; i <= n/maxFrameSize; i++ {
+			if _, err = s.GetFirmware(); err == nil {
+				break
+			}
+			if !errors.Is(err, ErrInvalidFrame) {
+				t.Fatalf("unexpected error after %d bytes of garbage, want %v, have %v", n, ErrInvalidFrame, err)
+			}
+		}
+		if err != nil {
+			t.Fatalf("valid frame after %d bytes of garbage not received: %s", n, err)
+		}
+	}
+}
+
+func TestReadAfterClose(t *testing.T) {
+
+	// Keep the reader busy, such that it may be blocked passing on a frame when
+	// the connection is closed
+	d := newMockDevice()
+	d.activeReports, d.frameInterval = true, time.Millisecond
+	s := newMockSensor(t, d)
+	time.Sleep(50 * time.Millisecond)
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("error closing sensor: %s", err)
+	}
+	for i := 0; ; i++ {
+		frame, err := s.readRawData(context.Background(), time.Second)
+		if err != nil {
+			if !IsTransportError(err) {
+				t.Fatalf("unexpected error reading from closed sensor, want transport error, have %v", err)
+			}
+			break
+		}
+		if frame == nil {
+			t.Fatalf("neither frame nor error returned from closed sensor")
+		}
+		if i > 1 {
+			t.Fatalf("unexpected number of frames read from closed sensor")
+		}
+	}
+}
+
+func TestRejectReadWithoutSizeOrTimeout(t *testing.T) {
+	_, err := New("/dev/does-not-exist", WithMinimumReadSize(0, 0))
+	if err == nil || errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected read settings to be rejected before opening the port, have %v", err)
+	}
+}
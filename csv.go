@@ -0,0 +1,94 @@
+package sds011
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// CSVHeader denotes the header of the CSV representation of data points (see
+// DataPoint.CSVRecord())
+var CSVHeader = []string{"timestamp", "pm25", "pm10", "device_id"}
+
+// CSVRecord returns the data point as CSV record (time stamp in RFC3339 format
+// with nanosecond precision unless configured otherwise via TimestampPrecision,
+// PM2.5, PM10 and device ID, see CSVHeader)
+// NOTE: Labels are not part of the CSV representation
+func (p DataPoint) CSVRecord() []string {
+	var deviceID string
+	if p.DeviceID != 0 {
+		deviceID = p.DeviceID.String()
+	}
+
+	return []string{
+		truncateTimeStamp(p.TimeStamp).Format(time.RFC3339Nano),
+		strconv.FormatFloat(p.PM25, 'f', -1, 64),
+		strconv.FormatFloat(p.PM10, 'f', -1, 64),
+		deviceID,
+	}
+}
+
+// ParseCSVRecord parses a data point from its CSV representation (see
+// DataPoint.CSVRecord(), the device ID column is optional)
+func ParseCSVRecord(record []string) (DataPoint, error) {
+	if len(record) < len(CSVHeader)-1 || len(record) > len(CSVHeader) {
+		return DataPoint{}, fmt.Errorf("invalid number of fields in CSV record, want %d, have %d", len(CSVHeader), len(record))
+	}
+
+	ts, err := time.Parse(time.RFC3339Nano, record[0])
+	if err != nil {
+		return DataPoint{}, fmt.Errorf("invalid timestamp `%s`: %w", record[0], err)
+	}
+	pm25, err := strconv.ParseFloat(record[1], 64)
+	if err != nil {
+		return DataPoint{}, fmt.Errorf("invalid PM2.5 value `%s`: %w", record[1], err)
+	}
+	pm10, err := strconv.ParseFloat(record[2], 64)
+	if err != nil {
+		return DataPoint{}, fmt.Errorf("invalid PM10 value `%s`: %w", record[2], err)
+	}
+
+	p := DataPoint{
+		TimeStamp: ts,
+		PM25:      pm25,
+		PM10:      pm10,
+	}
+	if len(record) > 3 && record[3] != "" {
+		if p.DeviceID, err = ParseDeviceID(record[3]); err != nil {
+			return DataPoint{}, fmt.Errorf("invalid device ID `%s`: %w", record[3], err)
+		}
+	}
+
+	return p, nil
+}
+
+// ReadCSV reads all data points from CSV data (e.g. a log written using
+// DataPoint.CSVRecord()), skipping the header (if present)
+func ReadCSV(r io.Reader) ([]DataPoint, error) {
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	var points []DataPoint
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return points, nil
+			}
+			return nil, fmt.Errorf("error reading CSV data: %w", err)
+		}
+		if line == 1 && len(record) > 0 && record[0] == CSVHeader[0] {
+			continue
+		}
+
+		p, err := ParseCSVRecord(record)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing line %d: %w", line, err)
+		}
+		points = append(points, p)
+	}
+}
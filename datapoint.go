@@ -1,15 +1,47 @@
 package sds011
 
 import (
+	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// TimestampPrecision denotes the precision of the time stamps in all serialized
+// representations of data points (JSON, text, CSV and log fields), e.g. time.Second
+// to reduce storage size and visual clutter for a sensor reporting at ~1 Hz. Time
+// stamps are truncated accordingly, 0 retains full (nanosecond) precision.
+// NOTE: Has to be set before any data points are serialized (e.g. during startup)
+var TimestampPrecision time.Duration
+
 // DataPoint denotes a set of data taken at a specific point in time
+// NOTE: PM25 / PM10 may be NaN if the value is invalid (see WithInvalidAsNaN())
 type DataPoint struct {
 	TimeStamp time.Time
 	PM25      float64
 	PM10      float64
+	DeviceID  DeviceID          `json:",omitempty"`
+	Labels    map[string]string `json:",omitempty"`
+
+	// Seq denotes the sequence number of the data point within a stream (starting
+	// at 1 for each call to Stream(), 0 for data points not obtained via a stream)
+	Seq uint64 `json:",omitempty"`
+
+	// CountPM25 / CountPM10 denote the raw (unscaled) counts reported by the
+	// device (only populated if enabled, see WithRawCounts())
+	CountPM25 uint16 `json:",omitempty"`
+	CountPM10 uint16 `json:",omitempty"`
+
+	// WarmingUp denotes that the data point is the first (all-zero) reading after
+	// waking the device (see WithWakeZeroPolicy())
+	WarmingUp bool `json:",omitempty"`
+
+	// EstimatedSampleTime denotes the estimated time the device took the sample,
+	// as opposed to the reception time in TimeStamp (see WithSampleTimeEstimation())
+	EstimatedSampleTime *time.Time `json:",omitempty"`
+
+	pooled bool
 }
 
 // String returns a well-formatted string for the data point, fulfilling the Stringer interface
@@ -18,3 +50,186 @@ func (p *DataPoint) String() string {
 		p.PM25,
 		p.PM10)
 }
+
+// Diff returns a human-readable, field-by-field description of all differences
+// between the data point and another one (one line per differing field), or an
+// empty string if both are identical
+func (p DataPoint) Diff(other DataPoint) string {
+	var diffs []string
+
+	if !p.TimeStamp.Equal(other.TimeStamp) {
+		diffs = append(diffs, fmt.Sprintf("TimeStamp: %s != %s (Δ %v)",
+			p.TimeStamp.Format(time.RFC3339Nano), other.TimeStamp.Format(time.RFC3339Nano), other.TimeStamp.Sub(p.TimeStamp)))
+	}
+	if p.PM25 != other.PM25 {
+		diffs = append(diffs, fmt.Sprintf("PM25: %v != %v (Δ %v)", p.PM25, other.PM25, other.PM25-p.PM25))
+	}
+	if p.PM10 != other.PM10 {
+		diffs = append(diffs, fmt.Sprintf("PM10: %v != %v (Δ %v)", p.PM10, other.PM10, other.PM10-p.PM10))
+	}
+	if p.DeviceID != other.DeviceID {
+		diffs = append(diffs, fmt.Sprintf("DeviceID: %s != %s", p.DeviceID, other.DeviceID))
+	}
+	if !equalLabels(p.Labels, other.Labels) {
+		diffs = append(diffs, fmt.Sprintf("Labels: %v != %v", p.Labels, other.Labels))
+	}
+
+	return strings.Join(diffs, "\n")
+}
+
+// LogFields returns the data point as a set of fields for structured logging
+// (pm25, pm10, ts and, if set, device_id and all labels), independent of any
+// specific logging library
+func (p DataPoint) LogFields() map[string]interface{} {
+	fields := make(map[string]interface{}, len(p.Labels)+4)
+	for k, v := range p.Labels {
+		fields[k] = v
+	}
+
+	fields["ts"] = truncateTimeStamp(p.TimeStamp)
+	fields["pm25"] = p.PM25
+	fields["pm10"] = p.PM10
+	if p.DeviceID != 0 {
+		fields["device_id"] = p.DeviceID.String()
+	}
+
+	return fields
+}
+
+// ToMap returns the data point as a map for generic encoders (e.g. msgpack / CBOR)
+// using stable keys: timestamp (time.Time), pm25 / pm10 (float64) and, if set
+// (mirroring the JSON representation), device_id (string), labels (map[string]string),
+// seq (uint64), count_pm25 / count_pm10 (uint16), warming_up (bool) and
+// estimated_sample_time (time.Time). Time stamps honor TimestampPrecision.
+func (p DataPoint) ToMap() map[string]interface{} {
+	res := map[string]interface{}{
+		"timestamp": truncateTimeStamp(p.TimeStamp),
+		"pm25":      p.PM25,
+		"pm10":      p.PM10,
+	}
+
+	if p.DeviceID != 0 {
+		res["device_id"] = p.DeviceID.String()
+	}
+	if len(p.Labels) > 0 {
+		res["labels"] = copyLabels(p.Labels)
+	}
+	if p.Seq != 0 {
+		res["seq"] = p.Seq
+	}
+	if p.CountPM25 != 0 {
+		res["count_pm25"] = p.CountPM25
+	}
+	if p.CountPM10 != 0 {
+		res["count_pm10"] = p.CountPM10
+	}
+	if p.WarmingUp {
+		res["warming_up"] = true
+	}
+	if p.EstimatedSampleTime != nil {
+		res["estimated_sample_time"] = truncateTimeStamp(*p.EstimatedSampleTime)
+	}
+
+	return res
+}
+
+// MarshalText returns a compact, machine-parseable single-line representation of
+// the data point ("<RFC3339 timestamp> <PM2.5> <PM10>"), fulfilling the
+// encoding.TextMarshaler interface
+// NOTE: Device ID and labels are not part of the text representation
+func (p DataPoint) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%s %s %s",
+		truncateTimeStamp(p.TimeStamp).Format(time.RFC3339Nano),
+		strconv.FormatFloat(p.PM25, 'f', -1, 64),
+		strconv.FormatFloat(p.PM10, 'f', -1, 64))), nil
+}
+
+// UnmarshalText parses the representation created by MarshalText(), fulfilling
+// the encoding.TextUnmarshaler interface
+func (p *DataPoint) UnmarshalText(text []byte) error {
+	fields := strings.Fields(string(text))
+	if len(fields) != 3 {
+		return fmt.Errorf("invalid data point `%s`: expected 3 fields, have %d", text, len(fields))
+	}
+
+	ts, err := time.Parse(time.RFC3339Nano, fields[0])
+	if err != nil {
+		return fmt.Errorf("invalid timestamp in data point: %w", err)
+	}
+	pm25, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil {
+		return fmt.Errorf("invalid PM2.5 value in data point: %w", err)
+	}
+	pm10, err := strconv.ParseFloat(fields[2], 64)
+	if err != nil {
+		return fmt.Errorf("invalid PM10 value in data point: %w", err)
+	}
+
+	*p = DataPoint{
+		TimeStamp: ts,
+		PM25:      pm25,
+		PM10:      pm10,
+	}
+
+	return nil
+}
+
+// dataPointJSON denotes the JSON representation of a data point (required to
+// prevent the JSON encoding from falling back to MarshalText())
+type dataPointJSON DataPoint
+
+// MarshalJSON returns the JSON representation of the data point, fulfilling the
+// json.Marshaler interface
+func (p DataPoint) MarshalJSON() ([]byte, error) {
+	p.TimeStamp = truncateTimeStamp(p.TimeStamp)
+	if p.EstimatedSampleTime != nil {
+		est := truncateTimeStamp(*p.EstimatedSampleTime)
+		p.EstimatedSampleTime = &est
+	}
+
+	return json.Marshal(dataPointJSON(p))
+}
+
+// UnmarshalJSON parses the JSON representation of the data point, fulfilling the
+// json.Unmarshaler interface
+func (p *DataPoint) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, (*dataPointJSON)(p))
+}
+
+// copyLabels creates a copy of a set of labels
+func copyLabels(labels map[string]string) map[string]string {
+	if labels == nil {
+		return nil
+	}
+
+	res := make(map[string]string, len(labels))
+	for k, v := range labels {
+		res[k] = v
+	}
+
+	return res
+}
+
+// equalLabels determines if two sets of labels are identical
+func equalLabels(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || v != w {
+			return false
+		}
+	}
+
+	return true
+}
+
+// truncateTimeStamp truncates a time stamp to the configured precision (see
+// TimestampPrecision)
+func truncateTimeStamp(ts time.Time) time.Time {
+	if TimestampPrecision <= 0 {
+		return ts
+	}
+
+	return ts.Truncate(TimestampPrecision)
+}
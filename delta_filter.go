@@ -0,0 +1,115 @@
+package sds011
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// DeltaFilterConfig denotes the configuration of a DeltaFilter
+type DeltaFilterConfig struct {
+
+	// AbsThreshold denotes the minimum absolute change (in μg / ㎥) of PM2.5 or
+	// PM10 for a data point to pass (0 disables the absolute threshold)
+	AbsThreshold float64
+
+	// RelThreshold denotes the minimum relative change (e.g. 0.1 for 10%) of
+	// PM2.5 or PM10 for a data point to pass (0 disables the relative threshold)
+	RelThreshold float64
+
+	// MaxHold denotes the maximum time after which a data point passes regardless
+	// of any change (0 disables the maximum hold interval)
+	MaxHold time.Duration
+}
+
+// DeltaFilter denotes a deadband filter that only passes data points whose
+// values changed meaningfully since the last passed data point
+type DeltaFilter struct {
+	cfg   DeltaFilterConfig
+	last  *DataPoint
+	mutex sync.Mutex
+}
+
+// NewDeltaFilter creates a new DeltaFilter
+func NewDeltaFilter(cfg DeltaFilterConfig) *DeltaFilter {
+	return &DeltaFilter{
+		cfg: cfg,
+	}
+}
+
+// Pass determines if a data point should be emitted, i.e. if either PM2.5 or PM10
+// exceed one of the thresholds (or become invalid / valid again, see
+// WithInvalidAsNaN()) or if the maximum hold interval has elapsed
+// The first data point always passes
+func (f *DeltaFilter) Pass(p DataPoint) bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.last == nil ||
+		(f.cfg.MaxHold > 0 && p.TimeStamp.Sub(f.last.TimeStamp) >= f.cfg.MaxHold) ||
+		f.exceeds(f.last.PM25, p.PM25) ||
+		f.exceeds(f.last.PM10, p.PM10) {
+		f.last = &p
+		return true
+	}
+
+	return false
+}
+
+// Reset resets the filter (the next data point will pass)
+func (f *DeltaFilter) Reset() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.last = nil
+}
+
+func (f *DeltaFilter) exceeds(last, current float64) bool {
+
+	// A transition from / to an invalid (NaN) value always constitutes a change
+	if math.IsNaN(last) || math.IsNaN(current) {
+		return math.IsNaN(last) != math.IsNaN(current)
+	}
+
+	delta := math.Abs(current - last)
+	if f.cfg.AbsThreshold > 0 && delta > f.cfg.AbsThreshold {
+		return true
+	}
+	if f.cfg.RelThreshold > 0 && delta > f.cfg.RelThreshold*math.Abs(last) {
+		return true
+	}
+
+	return false
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// FilterSink denotes a Sink that only forwards data points passing a filter
+// function to an underlying sink
+type FilterSink struct {
+	sink   Sink
+	filter func(DataPoint) bool
+}
+
+// NewFilterSink creates a new FilterSink (e.g. using a DeltaFilter's Pass method
+// as filter)
+func NewFilterSink(sink Sink, filter func(DataPoint) bool) *FilterSink {
+	return &FilterSink{
+		sink:   sink,
+		filter: filter,
+	}
+}
+
+// Write forwards the data point to the underlying sink if it passes the filter
+func (f *FilterSink) Write(p DataPoint) error {
+	if !f.filter(p) {
+		return nil
+	}
+
+	return f.sink.Write(p)
+}
+
+// Close closes the underlying sink
+func (f *FilterSink) Close() error {
+	return f.sink.Close()
+}
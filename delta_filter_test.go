@@ -0,0 +1,32 @@
+package sds011
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDeltaFilterNaN(t *testing.T) {
+
+	f := NewDeltaFilter(DeltaFilterConfig{
+		AbsThreshold: 5,
+	})
+
+	for i, cs := range []struct {
+		pm25, pm10 float64
+		expected   bool
+	}{
+		{10, 20, true},
+		{11, 21, false},
+
+		// A value becoming invalid passes once, as does its recovery
+		{math.NaN(), 21, true},
+		{math.NaN(), 21, false},
+		{11, 21, true},
+		{12, math.NaN(), true},
+		{12, 21, true},
+	} {
+		if pass := f.Pass(DataPoint{PM25: cs.pm25, PM10: cs.pm10}); pass != cs.expected {
+			t.Fatalf("unexpected filter result for data point #%d (%v / %v), want %v, have %v", i, cs.pm25, cs.pm10, cs.expected, pass)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package sds011
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+)
+
+// DeviceID denotes the (two byte) ID of a device, in wire order (e.g. 0xA160 for
+// a device sending the ID bytes 0xA1, 0x60)
+type DeviceID uint16
+
+// DeviceIDAll denotes the broadcast ID addressing all devices
+const DeviceIDAll = DeviceID(0xffff)
+
+// ParseDeviceID parses a device ID from its hex representation (e.g. "a160")
+func ParseDeviceID(s string) (DeviceID, error) {
+	id, err := strconv.ParseUint(s, 16, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid device ID `%s`: %w", s, err)
+	}
+
+	return DeviceID(id), nil
+}
+
+// String returns the hex representation of the device ID, fulfilling the Stringer interface
+func (id DeviceID) String() string {
+	return fmt.Sprintf("%04x", uint16(id))
+}
+
+// decodeDeviceID extracts the device ID from a (validated) frame
+func decodeDeviceID(rxData []byte) DeviceID {
+	return DeviceID(binary.BigEndian.Uint16(rxData[6:8]))
+}
+
+// MarshalText returns the hex representation of the device ID, fulfilling the
+// encoding.TextMarshaler interface
+func (id DeviceID) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+// UnmarshalText parses the hex representation of the device ID, fulfilling the
+// encoding.TextUnmarshaler interface
+func (id *DeviceID) UnmarshalText(text []byte) error {
+	parsed, err := ParseDeviceID(string(text))
+	if err != nil {
+		return err
+	}
+	*id = parsed
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package sds011
+
+import (
+	"context"
+	"fmt"
+)
+
+// Firmware denotes the firmware version (i.e. release date) of a device
+type Firmware struct {
+	Year  int
+	Month int
+	Day   int
+}
+
+// String returns the firmware version in the format reported by GetFirmware(),
+// fulfilling the Stringer interface
+func (f Firmware) String() string {
+	return fmt.Sprintf("%d-%d-%d", f.Year, f.Month, f.Day)
+}
+
+// decodeFirmware extracts the firmware version from a (validated) reply
+func decodeFirmware(rxData []byte) Firmware {
+	return Firmware{
+		Year:  2000 + int(rxData[3]),
+		Month: int(rxData[4]),
+		Day:   int(rxData[5]),
+	}
+}
+
+// Diagnostics denotes all diagnostic information available from a device
+type Diagnostics struct {
+	Firmware      Firmware
+	DeviceID      DeviceID
+	WorkMode      WorkMode
+	ReportingMode ReportingMode
+	WorkPeriod    int
+}
+
+// GetDiagnostics gathers all diagnostic information available from the device
+// (which has to be awake). Any error (including a timeout, e.g. if the firmware
+// of the device does not answer a query) aborts the probe.
+// NOTE: The SDS011 protocol does not document any extended diagnostic commands
+// (e.g. uptime / operating hours) for any firmware version, hence only the
+// information available via the documented commands is gathered
+func (s *SDS011) GetDiagnostics() (*Diagnostics, error) {
+
+	rxData, err := s.executeCommand(context.Background(), s.timeout, CommandFirmware)
+	if err != nil {
+		return nil, fmt.Errorf("error reading firmware version: %w", err)
+	}
+	diag := &Diagnostics{
+		Firmware: decodeFirmware(rxData),
+		DeviceID: decodeDeviceID(rxData),
+	}
+	s.setCachedDeviceID(diag.DeviceID)
+
+	probes := []struct {
+		name string
+		fn   func() error
+	}{
+		{"work mode", func() (err error) {
+			diag.WorkMode, err = s.RefreshWorkMode()
+			return
+		}},
+		{"reporting mode", func() (err error) {
+			diag.ReportingMode, err = s.RefreshReportingMode()
+			return
+		}},
+		{"work period", func() (err error) {
+			diag.WorkPeriod, err = s.GetWorkPeriod()
+			return
+		}},
+	}
+	for _, probe := range probes {
+		if err := probe.fn(); err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", probe.name, err)
+		}
+	}
+
+	return diag, nil
+}
@@ -0,0 +1,36 @@
+package sds011
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGetDiagnostics(t *testing.T) {
+
+	d := newMockDevice()
+	d.workPeriod = 5
+	s := newMockSensor(t, d, WithTimeout(100*time.Millisecond))
+
+	diag, err := s.GetDiagnostics()
+	if err != nil {
+		t.Fatalf("error gathering diagnostics: %s", err)
+	}
+	if diag.Firmware != (Firmware{2018, 11, 16}) || diag.DeviceID != mockDeviceID ||
+		diag.WorkMode != WorkModeActive || diag.ReportingMode != ReportingModeQuery || diag.WorkPeriod != 5 {
+		t.Fatalf("unexpected diagnostics: %+v", diag)
+	}
+}
+
+func TestGetDiagnosticsTimeout(t *testing.T) {
+
+	// A timeout of any query is an error
+	d := newMockDevice()
+	d.ignored = map[Command]bool{CommandWorkingPeriod: true}
+	s := newMockSensor(t, d, WithTimeout(100*time.Millisecond))
+
+	diag, err := s.GetDiagnostics()
+	if !errors.Is(err, ErrTimeout) || diag != nil {
+		t.Fatalf("unexpected result, want %v, have %v (diagnostics: %+v)", ErrTimeout, err, diag)
+	}
+}
@@ -0,0 +1,112 @@
+package sds011
+
+import (
+	"errors"
+	"os"
+	"strings"
+)
+
+var (
+
+	// ErrTimeout denotes that no data was received from the device in time
+	ErrTimeout = errors.New("timeout while reading from serial port (device in sleep mode?)")
+
+	// ErrChecksumMismatch denotes that the checksum of a received frame is invalid
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+
+	// ErrInvalidFrame denotes that a received frame is malformed (e.g. has an
+	// invalid length or framing)
+	ErrInvalidFrame = errors.New("invalid frame")
+
+	// ErrActiveReportingMode denotes that data was queried while the device is in
+	// active reporting mode (see ActiveModeError)
+	ErrActiveReportingMode = errors.New("device is in active reporting mode")
+
+	// ErrPeriodicWorkMode denotes that an operation requiring continuous operation
+	// was attempted while a working period is configured (see SetWorkPeriod())
+	ErrPeriodicWorkMode = errors.New("device is configured for periodic operation")
+)
+
+// MultiError denotes a set of errors that occurred during a single operation
+type MultiError []error
+
+// Error returns all error messages, fulfilling the error interface
+func (e MultiError) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// Is determines if any of the errors matches the target, allowing to inspect
+// the set via errors.Is()
+// NOTE: Implemented explicitly since errors.Is() does not handle multiple wrapped
+// errors (Unwrap() []error) prior to Go 1.20
+func (e MultiError) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// As finds the first of the errors that matches the target (and if so, sets the
+// target to its value), allowing to inspect the set via errors.As()
+func (e MultiError) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ErrorOrNil returns nil if the set of errors is empty or the set itself otherwise
+func (e MultiError) ErrorOrNil() error {
+	if len(e) == 0 {
+		return nil
+	}
+
+	return e
+}
+
+// IsTransportError determines if an error was caused by the link to the device
+// (e.g. the serial port vanished, a network connection broke or the sensor was
+// closed), in which case the connection has to be re-established (see Reconnect())
+// The following errors are classified as transport errors:
+//   - ErrClosed
+//   - io.EOF, io.ErrUnexpectedEOF, io.ErrClosedPipe, net.ErrClosed, os.ErrClosed
+//   - *net.OpError and *os.PathError (e.g. I/O errors on the port)
+//
+// NOTE: ErrTimeout is neither a transport nor a protocol error, it usually
+// indicates that the device is asleep or (temporarily) unresponsive
+func IsTransportError(err error) bool {
+	var pathErr *os.PathError
+	return errors.Is(err, ErrClosed) ||
+		isConnectionError(err) ||
+		errors.As(err, &pathErr)
+}
+
+// IsProtocolError determines if an error was caused by invalid / unexpected data
+// received from the device while the link itself is intact, in which case the
+// operation may simply be retried
+// The following errors are classified as protocol errors:
+//   - ErrChecksumMismatch
+//   - ErrInvalidFrame
+//   - ErrUnexpectedPacket
+func IsProtocolError(err error) bool {
+	return isCorruptFrameError(err)
+}
+
+// isCorruptFrameError determines if an error was caused by a corrupt / unexpected
+// frame (as opposed to e.g. a timeout or a broken connection)
+func isCorruptFrameError(err error) bool {
+	return errors.Is(err, ErrChecksumMismatch) ||
+		errors.Is(err, ErrInvalidFrame) ||
+		errors.Is(err, ErrUnexpectedPacket)
+}
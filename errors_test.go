@@ -0,0 +1,34 @@
+package sds011
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestMultiErrorIsAs(t *testing.T) {
+
+	pathErr := &os.PathError{Op: "read", Path: "/dev/ttyUSB0", Err: os.ErrClosed}
+	err := fmt.Errorf("error shutting down: %w", MultiError{
+		fmt.Errorf("error setting sleep mode: %w", ErrTimeout),
+		fmt.Errorf("error closing sensor: %w", pathErr),
+	}.ErrorOrNil())
+
+	for _, target := range []error{ErrTimeout, os.ErrClosed} {
+		if !errors.Is(err, target) {
+			t.Fatalf("expected %v to match %v", err, target)
+		}
+	}
+	if errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("unexpected match of %v", ErrChecksumMismatch)
+	}
+
+	var target *os.PathError
+	if !errors.As(err, &target) || target != pathErr {
+		t.Fatalf("expected %v to yield %v, have %v", err, pathErr, target)
+	}
+	if !IsTransportError(err) {
+		t.Fatalf("expected %v to be classified as transport error", err)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/fako1024/sds011/examples/internal/commands"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	devicePath string
+)
+
+func main() {
+
+	// Parse command line parameters
+	readFlags()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands.Commands[flag.Arg(0)]
+	if !ok || flag.NArg()-1 != cmd.NArgs {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Initialize a new sds011 sensor
+	sensor, err := commands.OpenSensor(devicePath)
+	if err != nil {
+		logrus.StandardLogger().Fatalf("Error initializing sensor: %s", err)
+	}
+
+	err = commands.Run(sensor, os.Stdout, flag.Arg(0), flag.Args()[1:])
+	if closeErr := sensor.Close(); closeErr != nil {
+		logrus.StandardLogger().Errorf("Error closing %s: %s", devicePath, closeErr)
+	}
+	if err != nil {
+		logrus.StandardLogger().Fatalf("Error executing command `%s` on %s: %s", flag.Arg(0), devicePath, err)
+	}
+}
+
+// readFlags parses command line parameters
+func readFlags() {
+	flag.StringVar(&devicePath, "d", "/dev/ttyUSB0", "Device / socket path to connect to (\"sim\" for a simulated sensor)")
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] <command> [args]\n\nCommands:\n", os.Args[0])
+		commands.PrintUsage(flag.CommandLine.Output())
+
+		fmt.Fprintf(flag.CommandLine.Output(), "\nFlags:\n")
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+}
@@ -0,0 +1,179 @@
+// Package commands provides the commands shared by the interactive examples,
+// each mapping to the corresponding library call and printing its result, as
+// well as helpers shared by all examples (see OpenSensor())
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+
+	"github.com/fako1024/sds011"
+)
+
+// Command denotes a command operating on the sensor
+type Command struct {
+	Usage string
+	NArgs int
+
+	run func(sensor sds011.Sensor, out io.Writer, args []string) error
+}
+
+// Commands denotes all available commands (by name)
+var Commands = map[string]Command{
+	"firmware": {
+		Usage: "print the firmware version",
+		run:   printFirmware,
+	},
+	"get-mode": {
+		Usage: "print the work and reporting mode",
+		run:   printModes,
+	},
+	"set-mode": {
+		Usage: "<work|reporting> <mode>: set the work (sleep / active) or reporting (active / query) mode",
+		NArgs: 2,
+		run:   setMode,
+	},
+	"get-period": {
+		Usage: "print the working period (in minutes)",
+		run:   printWorkPeriod,
+	},
+	"set-period": {
+		Usage: "<minutes>: set the working period (0 = continuous)",
+		NArgs: 1,
+		run:   setWorkPeriod,
+	},
+	"query": {
+		Usage: "query and print a single data point",
+		run:   query,
+	},
+	"stream": {
+		Usage: "print all data points received from the sensor (in active reporting mode) until interrupted",
+		run:   stream,
+	},
+}
+
+// Run executes the command with the provided name and arguments, printing its
+// result to out
+func Run(sensor sds011.Sensor, out io.Writer, name string, args []string) error {
+	cmd, ok := Commands[name]
+	if !ok {
+		return fmt.Errorf("unknown command `%s`", name)
+	}
+	if len(args) != cmd.NArgs {
+		return fmt.Errorf("invalid number of arguments for command `%s`, want %d, have %d", name, cmd.NArgs, len(args))
+	}
+
+	return cmd.run(sensor, out, args)
+}
+
+// PrintUsage prints all available commands and their usage
+func PrintUsage(out io.Writer) {
+	names := make([]string, 0, len(Commands))
+	for name := range Commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(out, "  %-12s %s\n", name, Commands[name].Usage)
+	}
+}
+
+func printFirmware(sensor sds011.Sensor, out io.Writer, _ []string) error {
+	firmware, err := sensor.GetFirmware()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, firmware)
+	return nil
+}
+
+func printModes(sensor sds011.Sensor, out io.Writer, _ []string) error {
+	workMode, err := sensor.GetWorkMode()
+	if err != nil {
+		return err
+	}
+	reportingMode, err := sensor.GetReportingMode()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "work mode: %s\nreporting mode: %s\n", workMode, reportingMode)
+	return nil
+}
+
+func setMode(sensor sds011.Sensor, out io.Writer, args []string) error {
+	switch args[0] {
+	case "work":
+		mode, err := sds011.ParseWorkMode(args[1])
+		if err != nil {
+			return err
+		}
+		return sensor.SetWorkMode(mode)
+	case "reporting":
+		mode, err := sds011.ParseReportingMode(args[1])
+		if err != nil {
+			return err
+		}
+		return sensor.SetReportingMode(mode)
+	}
+
+	return fmt.Errorf("invalid mode type `%s`, must be one of work / reporting", args[0])
+}
+
+func printWorkPeriod(sensor sds011.Sensor, out io.Writer, _ []string) error {
+	period, err := sensor.GetWorkPeriod()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, period)
+	return nil
+}
+
+func setWorkPeriod(sensor sds011.Sensor, out io.Writer, args []string) error {
+	period, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid working period `%s`: %w", args[0], err)
+	}
+
+	return sensor.SetWorkPeriod(period)
+}
+
+func query(sensor sds011.Sensor, out io.Writer, _ []string) error {
+	dataPoint, err := sensor.QueryData()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, dataPoint)
+	return nil
+}
+
+func stream(sensor sds011.Sensor, out io.Writer, _ []string) error {
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	dataChan, errChan := sensor.Stream(ctx)
+	for {
+		select {
+		case dataPoint, ok := <-dataChan:
+			if !ok {
+				return nil
+			}
+			fmt.Fprintln(out, &dataPoint)
+		case err, ok := <-errChan:
+			if !ok {
+				errChan = nil
+				continue
+			}
+			fmt.Fprintf(out, "Error reading data: %s\n", err)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package commands
+
+import "github.com/fako1024/sds011"
+
+// SimulatedDevicePath denotes the device path selecting a simulated sensor
+const SimulatedDevicePath = "sim"
+
+// OpenSensor opens the sensor at the provided path using the provided options
+// (or a simulated sensor if the path is SimulatedDevicePath, applying all options
+// applicable to it, see SimulatedSensorConfig.WithOptions())
+func OpenSensor(path string, opts ...sds011.Option) (sds011.Sensor, error) {
+	if path == SimulatedDevicePath {
+		return sds011.NewSimulatedSensor(sds011.DefaultSimulatedSensorConfig.WithOptions(opts...)), nil
+	}
+
+	return sds011.New(path, opts...)
+}
@@ -1,10 +1,15 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/fako1024/sds011"
+	"github.com/fako1024/sds011/examples/internal/commands"
 	"github.com/sirupsen/logrus"
 )
 
@@ -18,11 +23,22 @@ func main() {
 	readFlags()
 
 	// Initialize a new sds011 sensor
-	sensor, err := sds011.New(devicePath)
+	sensor, err := commands.OpenSensor(devicePath)
 	if err != nil {
-		logrus.StandardLogger().Fatalf("Error opening %s: %s", devicePath, err)
+		logrus.StandardLogger().Fatalf("Error initializing sensor: %s", err)
 	}
 
+	// Log data until the program is interrupted / terminated
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	logLoop(ctx, sensor)
+}
+
+// logLoop continuously reads and logs data from the sensor until the context
+// is cancelled
+func logLoop(ctx context.Context, sensor sds011.Sensor) {
+
 	// Ensure that device is active, then enable query mode
 	if err := sensor.SetWorkMode(sds011.WorkModeActive); err != nil {
 		logrus.StandardLogger().Errorf("Error setting active mode on %s: %s", devicePath, err)
@@ -34,11 +50,9 @@ func main() {
 	// Ensure that the sensor is put in sleep mode after termination to conserve
 	// lifetime of the laser
 	defer func() {
-		if err := sensor.SetWorkMode(sds011.WorkModeSleep); err != nil {
-			logrus.StandardLogger().Errorf("Error setting sleep mode on %s: %s", devicePath, err)
+		if err := sensor.Shutdown(); err != nil {
+			logrus.StandardLogger().Errorf("Error shutting down %s: %s", devicePath, err)
 		}
-
-		sensor.Close()
 	}()
 
 	// Continuously put the device to active mode for 10 seconds, read out the data
@@ -50,16 +64,19 @@ func main() {
 		if err := sensor.SetWorkMode(sds011.WorkModeActive); err != nil {
 			logrus.StandardLogger().Errorf("Error setting active mode on %s: %s", devicePath, err)
 		}
-		time.Sleep(30 * time.Second)
+		if !sleep(ctx, 30*time.Second) {
+			return
+		}
 
 		// Read single data point
 		dataPoint, err := sensor.QueryData()
 		if err != nil {
 			logrus.StandardLogger().Errorf("Error reading data from %s: %s", devicePath, err)
-		}
+		} else {
 
-		// Log data
-		logrus.StandardLogger().Infof("Read data from %s: %s", devicePath, dataPoint)
+			// Log data
+			logrus.StandardLogger().WithFields(dataPoint.LogFields()).Infof("Read data from %s", devicePath)
+		}
 
 		// Put sensor to sleep mode
 		if err := sensor.SetWorkMode(sds011.WorkModeSleep); err != nil {
@@ -67,13 +84,26 @@ func main() {
 		}
 
 		// Wait 5 minutes to perform the next measurement
-		time.Sleep(5 * time.Minute)
+		if !sleep(ctx, 5*time.Minute) {
+			return
+		}
+	}
+}
+
+// sleep waits for the provided duration, returning false if the context is
+// cancelled in the meantime
+func sleep(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
 	}
 }
 
 // readFlags parses command line parameters
 func readFlags() {
-	flag.StringVar(&devicePath, "d", "/dev/ttyUSB0", "Device / socket path to connect to")
+	flag.StringVar(&devicePath, "d", "/dev/ttyUSB0", "Device / socket path to connect to (\"sim\" for a simulated sensor)")
 
 	flag.Parse()
 }
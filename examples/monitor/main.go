@@ -1,33 +1,41 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
-	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/fako1024/sds011"
+	"github.com/fako1024/sds011/examples/internal/commands"
 	"github.com/labstack/echo"
 	"github.com/sirupsen/logrus"
 )
 
-// Health denotes the result of a health check
-type Health struct {
-	OK      bool
-	Details string
-}
-
 var maxDataAge = time.Minute
 
+// historySize denotes the number of data points served via the history endpoint
+const historySize = 288
+
 // Simple global variables to hold configuration / data
 var (
+	configPath       string
 	devicePath       string
 	serverEndpoint   string
 	spinUpDuration   time.Duration
 	measurementDelay time.Duration
+	errorRepeat      time.Duration
+	modeCheck        time.Duration
+	workPeriod       int
+	calibration      = sds011.DefaultCalibration
 
 	currentData *sds011.DataPoint
-	health      *Health
+	history     = sds011.NewHistory(historySize)
+	lastErr     error
 )
 
 func main() {
@@ -38,106 +46,77 @@ func main() {
 	// Start the echo server
 	go startServer()
 
-	// Continuously try looping / extracting data (wrapped in additional loop since
-	// the device occasionally loses connection)
-	for {
-		readLoop()
-
-		// Back off for ten seconds to allow device to (re-)settle
-		time.Sleep(10 * time.Second)
+	// Continuously perform measurements (the loop takes care of reconnecting to the
+	// device since it occasionally loses connection)
+	loopCfg := sds011.DefaultLoopConfig(devicePath)
+	loopCfg.Open = func() (sds011.Sensor, error) {
+		return commands.OpenSensor(devicePath, sds011.WithCalibration(calibration))
+	}
+	loopCfg.SpinUp = spinUpDuration
+	loopCfg.MeasurementDelay = measurementDelay
+	loopCfg.WorkPeriod = workPeriod
+	loopCfg.ErrorRepeatInterval = errorRepeat
+	loopCfg.ModeCheckInterval = modeCheck
+
+	// Run the loop until the program is interrupted / terminated (the loop puts
+	// the device to sleep before returning)
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	if err := sds011.RunLoop(ctx, loopCfg, handleData, handleHealth); err != nil && !errors.Is(err, context.Canceled) {
+		logrus.StandardLogger().Fatalf("Error running measurement loop on %s: %s", devicePath, err)
 	}
 }
 
-// readLoop continuously reads lines from the device
-func readLoop() {
-
-	// Recover from potential panic when reading from device
-	defer func() {
-		if r := recover(); r != nil {
-			logrus.StandardLogger().Errorf("Panic recovered in readLoop(): %s", r)
-			health = &Health{
-				OK:      false,
-				Details: fmt.Sprintf("Panic recovered in readLoop(): %s", r),
-			}
-		}
-	}()
-
-	// Initialize a new sds011 sensor / station
-	sensor, err := sds011.New(devicePath)
-	if err != nil {
-		logrus.StandardLogger().Errorf("Error opening %s: %s", devicePath, err)
-		health = &Health{
-			OK:      false,
-			Details: fmt.Sprintf("Error opening %s: %s", devicePath, err),
-		}
+// handleData assigns newly read (and calibrated) data to current data
+func handleData(dataPoint *sds011.DataPoint) {
+	currentData = dataPoint
+	history.Add(*dataPoint)
+}
 
+// handleHealth logs any errors and keeps track of the latest one
+func handleHealth(h sds011.Health) {
+	if !h.OK {
+		logrus.StandardLogger().Errorf("Error on %s: %s", devicePath, h.Details)
+		lastErr = errors.New(h.Details)
 		return
 	}
-
-	// Ensure that device is active, then enable query mode
-	if err := sensor.SetWorkMode(sds011.WorkModeActive); err != nil {
-		logrus.StandardLogger().Errorf("Error setting active mode on %s: %s", devicePath, err)
-	}
-	if err := sensor.SetReportingMode(sds011.ReportingModeQuery); err != nil {
-		logrus.StandardLogger().Errorf("Error setting query reporting mode on %s: %s", devicePath, err)
-	}
-
-	// Ensure that the sensor is put in sleep mode after termination to conserve
-	// lifetime of the laser
-	defer func() {
-		if err := sensor.SetWorkMode(sds011.WorkModeSleep); err != nil {
-			logrus.StandardLogger().Errorf("Error setting sleep mode on %s: %s", devicePath, err)
-		}
-
-		sensor.Close()
-	}()
-
-	// Continuously put the device to active mode for 10 seconds, read out the data
-	// and put it back to sleep to conserve lifetime of the laser
-	for {
-
-		// Activate laser and fan, then wait for the device to settle and for
-		// stable air flow
-		if err := sensor.SetWorkMode(sds011.WorkModeActive); err != nil {
-			logrus.StandardLogger().Errorf("Error setting active mode on %s: %s", devicePath, err)
-		}
-		time.Sleep(spinUpDuration)
-
-		// Read single data point
-		dataPoint, err := sensor.QueryData()
-		if err != nil {
-			logrus.StandardLogger().Errorf("Error reading data from %s: %s", devicePath, err)
-			health = &Health{
-				OK:      false,
-				Details: fmt.Sprintf("Error reading data from %s: %s", devicePath, err),
-			}
-		}
-
-		// Put sensor to sleep mode
-		if err := sensor.SetWorkMode(sds011.WorkModeSleep); err != nil {
-			logrus.StandardLogger().Errorf("Error setting sleep mode on %s: %s", devicePath, err)
-		}
-
-		// Assign newly read data to current data
-		currentData = dataPoint
-		health = &Health{
-			OK: true,
-		}
-
-		// Wait to perform the next measurement
-		time.Sleep(measurementDelay)
+	if h.Details != "" {
+		logrus.StandardLogger().Warnf("Warning on %s: %s", devicePath, h.Details)
 	}
+	lastErr = nil
 }
 
 // readFlags parses command line parameters
 func readFlags() {
-	flag.StringVar(&devicePath, "d", "/dev/ttyUSB0", "Device / socket path to connect to")
+	flag.StringVar(&configPath, "c", "", "Path to JSON config file (takes precedence over all other flags)")
+	flag.StringVar(&devicePath, "d", "/dev/ttyUSB0", "Device / socket path to connect to (\"sim\" for a simulated sensor)")
 	flag.StringVar(&serverEndpoint, "s", "0.0.0.0:8000", "Server endpoint to listen on")
 	flag.DurationVar(&spinUpDuration, "spinUpDuration", 30*time.Second, "Time to wait for fan / air flow to settle before taking the measurement")
 	flag.DurationVar(&measurementDelay, "measurementDelay", 5*time.Minute, "Time to wait between measurements")
+	flag.DurationVar(&errorRepeat, "errorRepeat", 10*time.Minute, "Interval in which identical consecutive errors are logged (0 logs every error)")
+	flag.IntVar(&workPeriod, "workPeriod", sds011.WorkPeriodContinuous, "Working period of the device in minutes (0 for continuous operation, the duty cycle being controlled via measurementDelay)")
+	flag.DurationVar(&modeCheck, "modeCheck", 0, "Interval in which the device modes are checked for drift (e.g. after a power cycle) and re-applied (0 disables the check)")
 
 	flag.Parse()
 
+	// If a config file was provided, use its settings instead of the flags
+	if configPath != "" {
+		cfg, err := sds011.LoadConfig(configPath)
+		if err != nil {
+			logrus.StandardLogger().Fatalf("Error loading config: %s", err)
+		}
+
+		devicePath = cfg.DevicePath
+		serverEndpoint = cfg.ServerEndpoint
+		spinUpDuration = cfg.SpinUp
+		measurementDelay = cfg.MeasurementDelay
+		errorRepeat = cfg.ErrorRepeatInterval
+		modeCheck = cfg.ModeCheckInterval
+		workPeriod = cfg.WorkPeriod
+		calibration = cfg.Calibration
+	}
+
 	maxDataAge = 2 * measurementDelay
 }
 
@@ -150,6 +129,7 @@ func startServer() {
 	// Routes
 	e.GET("/", returnData)
 	e.GET("/health", returnHealth)
+	e.GET("/history", returnHistory)
 
 	// Start server
 	logrus.StandardLogger().Fatal(e.Start(serverEndpoint))
@@ -166,26 +146,12 @@ func returnData(c echo.Context) error {
 	return c.JSONPretty(http.StatusOK, currentData, "  ")
 }
 
+// History handler
+func returnHistory(c echo.Context) error {
+	return c.JSONPretty(http.StatusOK, history.Slice(), "  ")
+}
+
 // Health handler
 func returnHealth(c echo.Context) error {
-
-	// If there is no health (yet), signify via HTTP error
-	if health == nil {
-		return c.String(http.StatusNoContent, "No health data yet")
-	}
-
-	// If the current health is not ok, return it
-	if health.OK == false {
-		return c.JSONPretty(http.StatusOK, health, "  ")
-	}
-
-	// If the data is too old, return a warning
-	if currentData != nil && currentData.TimeStamp.Before(time.Now().Add(-2*time.Minute)) {
-		return c.JSONPretty(http.StatusOK, &Health{
-			OK:      false,
-			Details: fmt.Sprintf("Data is older than %v", maxDataAge),
-		}, "  ")
-	}
-
-	return c.JSONPretty(http.StatusOK, health, "  ")
+	return c.JSONPretty(http.StatusOK, sds011.HealthReport(currentData, maxDataAge, lastErr), "  ")
 }
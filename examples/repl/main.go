@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fako1024/sds011"
+	"github.com/fako1024/sds011/examples/internal/commands"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	devicePath string
+)
+
+func main() {
+
+	// Parse command line parameters
+	readFlags()
+
+	// Initialize a new sds011 sensor
+	sensor, err := commands.OpenSensor(devicePath)
+	if err != nil {
+		logrus.StandardLogger().Fatalf("Error initializing sensor: %s", err)
+	}
+	defer func() {
+		if err := sensor.Close(); err != nil {
+			logrus.StandardLogger().Errorf("Error closing %s: %s", devicePath, err)
+		}
+	}()
+
+	repl(sensor)
+}
+
+// repl reads commands from stdin and executes them until EOF (or an explicit exit)
+func repl(sensor sds011.Sensor) {
+
+	fmt.Println("Connected to", devicePath, "(type `help` for a list of commands)")
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for prompt(); scanner.Scan(); prompt() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "help":
+			commands.PrintUsage(os.Stdout)
+			fmt.Printf("  %-12s %s\n  %-12s %s\n", "help", "print this help", "exit", "leave the REPL")
+		case "exit", "quit":
+			return
+		default:
+			if err := commands.Run(sensor, os.Stdout, fields[0], fields[1:]); err != nil {
+				fmt.Println("Error:", err)
+			}
+		}
+	}
+	fmt.Println()
+}
+
+func prompt() {
+	fmt.Print("> ")
+}
+
+// readFlags parses command line parameters
+func readFlags() {
+	flag.StringVar(&devicePath, "d", "/dev/ttyUSB0", "Device / socket path to connect to (\"sim\" for a simulated sensor)")
+
+	flag.Parse()
+}
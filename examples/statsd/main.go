@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/fako1024/sds011"
+	"github.com/fako1024/sds011/examples/internal/commands"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	devicePath string
+	statsdAddr string
+	prefix     string
+	location   string
+)
+
+func main() {
+
+	// Parse command line parameters
+	readFlags()
+
+	// Initialize a new sds011 sensor
+	sensor, err := commands.OpenSensor(devicePath)
+	if err != nil {
+		logrus.StandardLogger().Fatalf("Error initializing sensor: %s", err)
+	}
+
+	// Initialize the StatsD sink
+	opts := []sds011.StatsDOption{
+		sds011.WithStatsDPrefix(prefix),
+		sds011.WithStatsDFlushInterval(10 * time.Second),
+	}
+	if location != "" {
+		opts = append(opts, sds011.WithStatsDTags(map[string]string{
+			"location": location,
+		}))
+	}
+	sink, err := sds011.NewStatsDSink(statsdAddr, opts...)
+	if err != nil {
+		logrus.StandardLogger().Fatalf("Error initializing StatsD sink: %s", err)
+	}
+	defer sink.Close()
+
+	// Ensure that device is active, then enable active reporting mode
+	if err := sensor.SetWorkMode(sds011.WorkModeActive); err != nil {
+		logrus.StandardLogger().Errorf("Error setting active mode on %s: %s", devicePath, err)
+	}
+	if err := sensor.SetReportingMode(sds011.ReportingModeActive); err != nil {
+		logrus.StandardLogger().Errorf("Error setting active reporting mode on %s: %s", devicePath, err)
+	}
+
+	// Ensure that the sensor is put in sleep mode after termination to conserve
+	// lifetime of the laser
+	defer func() {
+		if err := sensor.Shutdown(); err != nil {
+			logrus.StandardLogger().Errorf("Error shutting down %s: %s", devicePath, err)
+		}
+	}()
+
+	// Continuously forward all data points received from the sensor to StatsD
+	// until the program is interrupted / terminated
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	dataChan, errChan := sensor.Stream(ctx)
+	go func() {
+		for err := range errChan {
+			logrus.StandardLogger().Errorf("Error reading data from %s: %s", devicePath, err)
+		}
+	}()
+	for dataPoint := range dataChan {
+		if err := sink.Write(dataPoint); err != nil {
+			logrus.StandardLogger().Errorf("Error sending data to %s: %s", statsdAddr, err)
+		}
+	}
+}
+
+// readFlags parses command line parameters
+func readFlags() {
+	flag.StringVar(&devicePath, "d", "/dev/ttyUSB0", "Device / socket path to connect to (\"sim\" for a simulated sensor)")
+	flag.StringVar(&statsdAddr, "a", "localhost:8125", "StatsD endpoint (host:port) to send data to")
+	flag.StringVar(&prefix, "prefix", "sds011.", "Prefix of all metric names")
+	flag.StringVar(&location, "location", "", "Location tag attached to all metrics (optional)")
+
+	flag.Parse()
+}
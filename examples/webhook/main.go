@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/fako1024/sds011"
+	"github.com/fako1024/sds011/examples/internal/commands"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	devicePath string
+	webhookURL string
+	secret     string
+)
+
+func main() {
+
+	// Parse command line parameters
+	readFlags()
+
+	// Initialize a new sds011 sensor
+	sensor, err := commands.OpenSensor(devicePath)
+	if err != nil {
+		logrus.StandardLogger().Fatalf("Error initializing sensor: %s", err)
+	}
+
+	// Initialize the webhook sink
+	opts := []sds011.WebhookOption{
+		sds011.WithWebhookRetries(3, 5*time.Second),
+	}
+	if secret != "" {
+		opts = append(opts, sds011.WithWebhookSecret([]byte(secret)))
+	}
+	sink := sds011.NewWebhookSink(webhookURL, opts...)
+	defer sink.Close()
+
+	// Ensure that device is active, then enable active reporting mode
+	if err := sensor.SetWorkMode(sds011.WorkModeActive); err != nil {
+		logrus.StandardLogger().Errorf("Error setting active mode on %s: %s", devicePath, err)
+	}
+	if err := sensor.SetReportingMode(sds011.ReportingModeActive); err != nil {
+		logrus.StandardLogger().Errorf("Error setting active reporting mode on %s: %s", devicePath, err)
+	}
+
+	// Ensure that the sensor is put in sleep mode after termination to conserve
+	// lifetime of the laser
+	defer func() {
+		if err := sensor.Shutdown(); err != nil {
+			logrus.StandardLogger().Errorf("Error shutting down %s: %s", devicePath, err)
+		}
+	}()
+
+	// Continuously forward all data points received from the sensor to the webhook
+	// until the program is interrupted / terminated
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	dataChan, errChan := sensor.Stream(ctx)
+	go func() {
+		for err := range errChan {
+			logrus.StandardLogger().Errorf("Error reading data from %s: %s", devicePath, err)
+		}
+	}()
+	for dataPoint := range dataChan {
+		if err := sink.Write(dataPoint); err != nil {
+			logrus.StandardLogger().Errorf("Error posting data to %s: %s", webhookURL, err)
+		}
+	}
+}
+
+// readFlags parses command line parameters
+func readFlags() {
+	flag.StringVar(&devicePath, "d", "/dev/ttyUSB0", "Device / socket path to connect to (\"sim\" for a simulated sensor)")
+	flag.StringVar(&webhookURL, "u", "http://localhost:8080/", "Webhook URL to post data to")
+	flag.StringVar(&secret, "secret", "", "Secret to sign requests with (HMAC-SHA256, optional)")
+
+	flag.Parse()
+}
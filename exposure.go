@@ -0,0 +1,50 @@
+package sds011
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Dose denotes the cumulative exposure to PM2.5 / PM10 over a period of time (in
+// μg·h / ㎥, i.e. the time integral of the concentration)
+type Dose struct {
+	PM25 float64
+	PM10 float64
+
+	// DurationPM25 / DurationPM10 denote the time span covered by the integration
+	// (gaps caused by NaN values are excluded)
+	DurationPM25 time.Duration
+	DurationPM10 time.Duration
+}
+
+// ExposureDose estimates the cumulative exposure (in μg·h / ㎥) from a series of
+// data points sorted by time stamp, using trapezoidal integration in order to
+// account for uneven sampling. Segments adjacent to a NaN value are skipped (per
+// field), yielding a dose of zero if no valid segment exists.
+func ExposureDose(points []DataPoint) (Dose, error) {
+
+	var dose Dose
+	for i := 1; i < len(points); i++ {
+		prev, cur := points[i-1], points[i]
+		if cur.TimeStamp.Before(prev.TimeStamp) {
+			return Dose{}, fmt.Errorf("data points not sorted by time stamp (index %d)", i)
+		}
+
+		dt := cur.TimeStamp.Sub(prev.TimeStamp)
+		dose.PM25, dose.DurationPM25 = integrateSegment(dose.PM25, dose.DurationPM25, prev.PM25, cur.PM25, dt)
+		dose.PM10, dose.DurationPM10 = integrateSegment(dose.PM10, dose.DurationPM10, prev.PM10, cur.PM10, dt)
+	}
+
+	return dose, nil
+}
+
+// integrateSegment adds the trapezoidal integral of a single segment to a running
+// sum (skipping the segment if either value is NaN)
+func integrateSegment(sum float64, covered time.Duration, from, to float64, dt time.Duration) (float64, time.Duration) {
+	if math.IsNaN(from) || math.IsNaN(to) {
+		return sum, covered
+	}
+
+	return sum + (from+to)/2*dt.Hours(), covered + dt
+}
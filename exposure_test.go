@@ -0,0 +1,76 @@
+package sds011
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// series creates a series of data points at the provided offsets (relative to
+// a fixed start time), with values determined by fn
+func series(offsets []time.Duration, fn func(time.Duration) float64) []DataPoint {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	points := make([]DataPoint, 0, len(offsets))
+	for _, offset := range offsets {
+		points = append(points, DataPoint{
+			TimeStamp: start.Add(offset),
+			PM25:      fn(offset),
+			PM10:      2 * fn(offset),
+		})
+	}
+
+	return points
+}
+
+func TestExposureDoseConstant(t *testing.T) {
+
+	// Uneven sampling must not affect the dose of a constant series
+	offsets := []time.Duration{0, 10 * time.Minute, 15 * time.Minute, 45 * time.Minute, 2 * time.Hour}
+	dose, err := ExposureDose(series(offsets, func(time.Duration) float64 { return 12 }))
+	if err != nil {
+		t.Fatalf("error computing dose: %s", err)
+	}
+	if math.Abs(dose.PM25-24) > 1e-9 || math.Abs(dose.PM10-48) > 1e-9 {
+		t.Fatalf("unexpected dose, want 24 / 48, have %v / %v", dose.PM25, dose.PM10)
+	}
+	if dose.DurationPM25 != 2*time.Hour || dose.DurationPM10 != 2*time.Hour {
+		t.Fatalf("unexpected duration, want 2h, have %v / %v", dose.DurationPM25, dose.DurationPM10)
+	}
+}
+
+func TestExposureDoseRamp(t *testing.T) {
+
+	// A linear ramp from 0 to 30 μg / ㎥ over 3 hours is integrated exactly by
+	// the trapezoidal rule (regardless of sampling)
+	offsets := []time.Duration{0, 20 * time.Minute, time.Hour, 150 * time.Minute, 3 * time.Hour}
+	dose, err := ExposureDose(series(offsets, func(offset time.Duration) float64 { return 10 * offset.Hours() }))
+	if err != nil {
+		t.Fatalf("error computing dose: %s", err)
+	}
+	if math.Abs(dose.PM25-45) > 1e-9 || math.Abs(dose.PM10-90) > 1e-9 {
+		t.Fatalf("unexpected dose, want 45 / 90, have %v / %v", dose.PM25, dose.PM10)
+	}
+}
+
+func TestExposureDoseNaNAndOrdering(t *testing.T) {
+
+	points := series([]time.Duration{0, time.Hour, 2 * time.Hour, 3 * time.Hour}, func(time.Duration) float64 { return 10 })
+	points[2].PM25 = math.NaN()
+
+	// Both segments adjacent to the NaN value are skipped
+	dose, err := ExposureDose(points)
+	if err != nil {
+		t.Fatalf("error computing dose: %s", err)
+	}
+	if math.Abs(dose.PM25-10) > 1e-9 || dose.DurationPM25 != time.Hour {
+		t.Fatalf("unexpected PM2.5 dose, want 10 over 1h, have %v over %v", dose.PM25, dose.DurationPM25)
+	}
+	if math.Abs(dose.PM10-60) > 1e-9 || dose.DurationPM10 != 3*time.Hour {
+		t.Fatalf("unexpected PM10 dose, want 60 over 3h, have %v over %v", dose.PM10, dose.DurationPM10)
+	}
+
+	points[1], points[3] = points[3], points[1]
+	if _, err := ExposureDose(points); err == nil {
+		t.Fatalf("expected error for unsorted data points")
+	}
+}
@@ -0,0 +1,129 @@
+package sds011
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// pollInterval denotes the maximum time a read from a serial port waits for
+// input before checking if the port was closed in the meantime
+const pollInterval = 100 * time.Millisecond
+
+// configureFile sets the serial line settings (9600 baud, 8N1, raw mode) on an
+// open file descriptor
+func configureFile(f *os.File) error {
+	return controlFile(f, func(fd int) error {
+
+		t, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+		if err != nil {
+			return err
+		}
+
+		// Raw mode (no line editing / translation / echo)
+		t.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON | unix.IXOFF | unix.IXANY
+		t.Oflag &^= unix.OPOST
+		t.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+
+		// 9600 baud, 8 data bits, no parity, 1 stop bit
+		t.Cflag &^= unix.CSIZE | unix.PARENB | unix.CSTOPB | unix.CBAUD
+		t.Cflag |= unix.CS8 | unix.CREAD | unix.CLOCAL | unix.B9600
+		t.Ispeed, t.Ospeed = 9600, 9600
+
+		// Block until at least one byte is available
+		t.Cc[unix.VMIN], t.Cc[unix.VTIME] = 1, 0
+
+		return unix.IoctlSetTermios(fd, unix.TCSETS, t)
+	})
+}
+
+// flushFile discards all data received by the driver but not yet read (best
+// effort, e.g. the descriptor may not refer to a terminal)
+func flushFile(f *os.File) {
+	controlFile(f, func(fd int) error { // #nosec G104
+		return unix.IoctlSetInt(fd, unix.TCFLSH, unix.TCIFLUSH)
+	})
+}
+
+// controlFile invokes fn on the raw descriptor of a file
+// NOTE: In contrast to f.Fd(), this does not switch the descriptor to blocking mode
+func controlFile(f *os.File, fn func(fd int) error) error {
+	raw, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var fnErr error
+	if err := raw.Control(func(fd uintptr) {
+		fnErr = fn(int(fd))
+	}); err != nil {
+		return err
+	}
+
+	return fnErr
+}
+
+// pollFile wraps a serial port, waiting for input via poll() before each read
+// A blocking read cannot be interrupted by closing the port, hence the background
+// reader of a closed connection would otherwise remain blocked (keeping the port
+// open) and consume the data destined for its successor (e.g. after Reconnect())
+type pollFile struct {
+	*os.File
+	raw    syscall.RawConn
+	closed int32
+}
+
+// wrapFile wraps a serial port such that pending reads terminate (within
+// pollInterval) once the port is closed (see pollFile)
+func wrapFile(f *os.File) io.ReadWriteCloser {
+	raw, err := f.SyscallConn()
+	if err != nil {
+		return f
+	}
+
+	return &pollFile{
+		File: f,
+		raw:  raw,
+	}
+}
+
+// Read reads from the port once input is available, fulfilling the io.Reader interface
+func (p *pollFile) Read(b []byte) (int, error) {
+	for {
+		if atomic.LoadInt32(&p.closed) != 0 {
+			return 0, os.ErrClosed
+		}
+
+		var (
+			ready   bool
+			pollErr error
+		)
+		if err := p.raw.Control(func(fd uintptr) {
+			var n int
+			n, pollErr = unix.Poll([]unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}, int(pollInterval.Milliseconds()))
+			ready = n > 0
+		}); err != nil {
+			return 0, err
+		}
+		if pollErr != nil && !errors.Is(pollErr, unix.EINTR) {
+			return 0, pollErr
+		}
+
+		// Since input is available (or an error / hangup is pending), the read
+		// returns without blocking indefinitely
+		if ready {
+			return p.File.Read(b)
+		}
+	}
+}
+
+// Close closes the port, terminating any pending read, fulfilling the io.Closer interface
+func (p *pollFile) Close() error {
+	atomic.StoreInt32(&p.closed, 1)
+	return p.File.Close()
+}
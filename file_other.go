@@ -0,0 +1,24 @@
+//go:build !linux
+// +build !linux
+
+package sds011
+
+import (
+	"io"
+	"os"
+)
+
+// configureFile is a no-op on non-Linux platforms, the descriptor is expected
+// to be configured already
+func configureFile(f *os.File) error {
+	return nil
+}
+
+// flushFile is a no-op on non-Linux platforms
+func flushFile(f *os.File) {}
+
+// wrapFile returns the port as-is on non-Linux platforms
+// NOTE: A pending blocking read may hence outlive the connection it belongs to
+func wrapFile(f *os.File) io.ReadWriteCloser {
+	return f
+}
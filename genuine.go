@@ -0,0 +1,68 @@
+package sds011
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+
+	// genuineMaxReplyTime denotes the maximum time a genuine device takes to
+	// answer a command
+	genuineMaxReplyTime = 500 * time.Millisecond
+
+	// genuineMinFirmwareYear denotes the earliest firmware release of genuine devices
+	genuineMinFirmwareYear = 2015
+)
+
+// IsGenuine performs a best-effort check whether the connected device is a
+// genuine SDS011 (as opposed to a counterfeit / clone), based on the format of
+// the firmware version, the device ID, the structure of configuration replies
+// and the response timing. It returns the verdict and the reasons for it (i.e.
+// all failed checks). Only read-only commands are sent to the device, its
+// configuration remains untouched.
+// NOTE: This is a heuristic, clones may well pass all checks (and genuine
+// devices with unusual firmware may fail some)
+func (s *SDS011) IsGenuine() (bool, string, error) {
+
+	var issues []string
+
+	// Check the firmware version and the time taken to reply
+	rxData, elapsed, err := s.executeCommandTimed(context.Background(), s.timeout, DeviceIDAll, CommandFirmware)
+	if err != nil {
+		return false, "", fmt.Errorf("error reading firmware version: %w", err)
+	}
+	if elapsed > genuineMaxReplyTime {
+		issues = append(issues, fmt.Sprintf("slow reply (%v)", elapsed.Round(time.Millisecond)))
+	}
+	if fw := decodeFirmware(rxData); fw.Year < genuineMinFirmwareYear || fw.Year > time.Now().Year() ||
+		fw.Month < 1 || fw.Month > 12 || fw.Day < 1 || fw.Day > 31 {
+		issues = append(issues, fmt.Sprintf("implausible firmware version %s", fw))
+	}
+	if id := decodeDeviceID(rxData); id == 0 || id == DeviceIDAll {
+		issues = append(issues, fmt.Sprintf("implausible device ID %s", id))
+	}
+
+	// Check that configuration queries are confirmed as such (echoing the get
+	// flag) and report a valid setting
+	for _, cmd := range []Command{CommandReportingMode, CommandSleepWork} {
+		rxData, err := s.executeCommand(context.Background(), s.timeout, cmd, commandGet)
+		if err != nil {
+			return false, "", fmt.Errorf("error querying %s: %w", cmd, err)
+		}
+		if rxData[3] != commandGet {
+			issues = append(issues, fmt.Sprintf("unexpected confirmation byte %02x for %s query", rxData[3], cmd))
+		}
+		if rxData[4] > 0x01 {
+			issues = append(issues, fmt.Sprintf("invalid %s %02x", cmd, rxData[4]))
+		}
+	}
+
+	if len(issues) > 0 {
+		return false, strings.Join(issues, "; "), nil
+	}
+
+	return true, "all checks passed", nil
+}
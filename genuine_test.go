@@ -0,0 +1,25 @@
+package sds011
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsGenuineMinCommandInterval(t *testing.T) {
+
+	// Enforce a command interval above the maximum reply time of a genuine device
+	d := newMockDevice()
+	s := newMockSensor(t, d, WithMinCommandInterval(genuineMaxReplyTime+200*time.Millisecond))
+	if _, err := s.GetFirmware(); err != nil {
+		t.Fatalf("error querying firmware: %s", err)
+	}
+
+	// Waiting for the command slot must not be mistaken for a slow reply
+	genuine, reason, err := s.IsGenuine()
+	if err != nil {
+		t.Fatalf("error checking device: %s", err)
+	}
+	if !genuine {
+		t.Fatalf("mock device not considered genuine: %s", reason)
+	}
+}
@@ -0,0 +1,40 @@
+package sds011
+
+import (
+	"fmt"
+	"time"
+)
+
+// StaleAfter determines if a data point is older than maxAge (a missing data
+// point is always considered stale)
+func StaleAfter(last *DataPoint, maxAge time.Duration) bool {
+	return last == nil || time.Since(last.TimeStamp) > maxAge
+}
+
+// HealthReport assesses the health of a consumer of data points based on the
+// latest data point and the latest error (if any): It is considered healthy if
+// no error occurred and the latest data point is not older than maxAge
+func HealthReport(last *DataPoint, maxAge time.Duration, lastErr error) Health {
+	if lastErr != nil {
+		return Health{
+			OK:      false,
+			Details: lastErr.Error(),
+		}
+	}
+	if last == nil {
+		return Health{
+			OK:      false,
+			Details: "no data yet",
+		}
+	}
+	if StaleAfter(last, maxAge) {
+		return Health{
+			OK:      false,
+			Details: fmt.Sprintf("data is older than %v (last data point at %s)", maxAge, last.TimeStamp.Format(time.RFC3339)),
+		}
+	}
+
+	return Health{
+		OK: true,
+	}
+}
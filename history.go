@@ -0,0 +1,77 @@
+package sds011
+
+import "sync"
+
+// History denotes an in-memory ring buffer holding the latest data points,
+// overwriting the oldest one once full
+type History struct {
+	points []DataPoint
+	next   int
+	full   bool
+	mutex  sync.Mutex
+}
+
+// NewHistory creates a new History holding up to capacity data points
+func NewHistory(capacity int) *History {
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	return &History{
+		points: make([]DataPoint, capacity),
+	}
+}
+
+// Add stores a data point, overwriting the oldest one if the history is full
+func (h *History) Add(p DataPoint) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.points[h.next] = p
+	if h.next++; h.next == len(h.points) {
+		h.next, h.full = 0, true
+	}
+}
+
+// Slice returns a copy of all stored data points (oldest to newest)
+func (h *History) Slice() []DataPoint {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if !h.full {
+		return append([]DataPoint{}, h.points[:h.next]...)
+	}
+
+	return append(append(make([]DataPoint, 0, len(h.points)), h.points[h.next:]...), h.points[:h.next]...)
+}
+
+// Latest returns the most recently stored data point, ok is false if the
+// history is empty
+func (h *History) Latest() (p *DataPoint, ok bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if !h.full && h.next == 0 {
+		return nil, false
+	}
+
+	i := h.next - 1
+	if i < 0 {
+		i = len(h.points) - 1
+	}
+	latest := h.points[i]
+
+	return &latest, true
+}
+
+// Len returns the number of stored data points
+func (h *History) Len() int {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.full {
+		return len(h.points)
+	}
+
+	return h.next
+}
@@ -0,0 +1,160 @@
+package sds011
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// AwakePhase denotes the phase of the duty cycle managed by an AwakeController
+type AwakePhase int
+
+const (
+
+	// AwakePhaseSleeping denotes that the device is asleep and will be woken up
+	// on the next query
+	AwakePhaseSleeping AwakePhase = iota
+
+	// AwakePhaseActive denotes that the device is awake and serving queries
+	AwakePhaseActive
+
+	// AwakePhaseResting denotes that the device is asleep and must not be woken
+	// up until the minimum rest time has elapsed
+	AwakePhaseResting
+)
+
+// String returns the human-readable name of the phase, fulfilling the Stringer interface
+func (p AwakePhase) String() string {
+	switch p {
+	case AwakePhaseActive:
+		return "active"
+	case AwakePhaseResting:
+		return "resting"
+	}
+
+	return "sleeping"
+}
+
+// AwakeState denotes the current state of an AwakeController
+type AwakeState struct {
+	Phase       AwakePhase // Current phase of the duty cycle
+	Since       time.Time  // Start of the current phase
+	Activations uint64     // Number of times the device was woken up
+	LastErr     error      // Last error encountered while changing the work mode (if any)
+}
+
+// AwakeController denotes a controller managing the duty cycle of the laser for
+// high-frequency polling: The device is kept awake for up to maxActive after
+// being woken up, then put to sleep for (at least) minRest, balancing
+// responsiveness and lifetime of the laser
+type AwakeController struct {
+	sensor    Sensor
+	maxActive time.Duration
+	minRest   time.Duration
+
+	state  AwakeState
+	timer  *time.Timer
+	closed bool
+	mutex  sync.Mutex
+}
+
+// KeepAwake creates a new AwakeController for the provided sensor (which is
+// expected to be in query reporting mode)
+// NOTE: The first values after waking up the device may be less accurate since
+// the air flow has not settled yet (see RecommendedSpinUp())
+func KeepAwake(sensor Sensor, maxActive, minRest time.Duration) *AwakeController {
+	return &AwakeController{
+		sensor:    sensor,
+		maxActive: maxActive,
+		minRest:   minRest,
+		state: AwakeState{
+			Since: time.Now(),
+		},
+	}
+}
+
+// QueryData extract the current PM2.5 and PM10 values from the sensor, waking it
+// up if required (waiting for the end of the current rest period, if any)
+func (c *AwakeController) QueryData(ctx context.Context) (*DataPoint, error) {
+
+	// Wait for the end of the current rest period (if any) without holding the
+	// lock, allowing to retrieve the state / close the controller in the meantime
+	for {
+		c.mutex.Lock()
+		if c.closed {
+			c.mutex.Unlock()
+			return nil, ErrClosed
+		}
+		remaining := time.Until(c.state.Since.Add(c.minRest))
+		if c.state.Phase != AwakePhaseResting || remaining <= 0 {
+			break
+		}
+		c.mutex.Unlock()
+
+		if err := sleepContext(ctx, remaining); err != nil {
+			return nil, err
+		}
+	}
+	defer c.mutex.Unlock()
+
+	if c.state.Phase != AwakePhaseActive {
+		if err := c.sensor.SetWorkMode(WorkModeActive); err != nil {
+			c.state.LastErr = err
+			return nil, fmt.Errorf("error setting active mode: %w", err)
+		}
+		c.state.Phase, c.state.Since = AwakePhaseActive, time.Now()
+		c.state.Activations++
+		c.timer = time.AfterFunc(c.maxActive, c.rest)
+	}
+
+	return c.sensor.QueryDataContext(ctx)
+}
+
+// State returns the current state of the controller
+func (c *AwakeController) State() AwakeState {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	state := c.state
+	if state.Phase == AwakePhaseResting && time.Since(state.Since) >= c.minRest {
+		state.Phase, state.Since = AwakePhaseSleeping, state.Since.Add(c.minRest)
+	}
+
+	return state
+}
+
+// Close stops the controller and puts the device to sleep (if active), the
+// sensor itself is not closed
+func (c *AwakeController) Close() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.closed = true
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	if c.state.Phase != AwakePhaseActive {
+		return nil
+	}
+
+	c.state.Phase, c.state.Since = AwakePhaseSleeping, time.Now()
+	return c.sensor.SetWorkMode(WorkModeSleep)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// rest ends the active window by putting the device to sleep
+func (c *AwakeController) rest() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.closed || c.state.Phase != AwakePhaseActive {
+		return
+	}
+
+	if err := c.sensor.SetWorkMode(WorkModeSleep); err != nil {
+		c.state.LastErr = err
+	}
+	c.state.Phase, c.state.Since = AwakePhaseResting, time.Now()
+}
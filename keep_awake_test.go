@@ -0,0 +1,41 @@
+package sds011
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAwakeControllerRestDoesNotBlock(t *testing.T) {
+
+	c := KeepAwake(newTestSimulatedSensor(), 10*time.Millisecond, 500*time.Millisecond)
+	if _, err := c.QueryData(context.Background()); err != nil {
+		t.Fatalf("error querying data: %s", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	// The next query has to wait for the end of the rest period
+	done := make(chan error)
+	go func() {
+		_, err := c.QueryData(context.Background())
+		done <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	// Both the state and closing the controller must be accessible in the meantime
+	start := time.Now()
+	if state := c.State(); state.Phase != AwakePhaseResting || state.Activations != 1 {
+		t.Fatalf("unexpected controller state: %+v", state)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("error closing controller: %s", err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Fatalf("controller blocked for %v during rest period", elapsed)
+	}
+
+	if err := <-done; !errors.Is(err, ErrClosed) {
+		t.Fatalf("unexpected error of pending query, want %v, have %v", ErrClosed, err)
+	}
+}
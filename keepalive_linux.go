@@ -0,0 +1,26 @@
+package sds011
+
+import (
+	"net"
+
+	"golang.org/x/sys/unix"
+)
+
+// setKeepAliveCount sets the number of unacknowledged keepalive probes after
+// which a TCP connection is considered dead
+func setKeepAliveCount(conn *net.TCPConn, count int) error {
+
+	rawConn, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var sockErr error
+	if err := rawConn.Control(func(fd uintptr) {
+		sockErr = unix.SetsockoptInt(int(fd), unix.IPPROTO_TCP, unix.TCP_KEEPCNT, count)
+	}); err != nil {
+		return err
+	}
+
+	return sockErr
+}
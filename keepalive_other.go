@@ -0,0 +1,12 @@
+//go:build !linux
+// +build !linux
+
+package sds011
+
+import "net"
+
+// setKeepAliveCount is a no-op on non-Linux platforms, the system default
+// number of keepalive probes applies
+func setKeepAliveCount(conn *net.TCPConn, count int) error {
+	return nil
+}
@@ -0,0 +1,132 @@
+package sds011
+
+import (
+	"fmt"
+
+	"github.com/jacobsa/go-serial/serial"
+)
+
+// lineProbeAttempts denotes the number of firmware queries attempted with the
+// current line settings before alternatives are probed (see WithLineSettingsProbe())
+const lineProbeAttempts = 3
+
+// Parity denotes the parity mode of the serial line
+type Parity int
+
+const (
+
+	// ParityNone denotes no parity bit
+	ParityNone Parity = iota
+
+	// ParityEven denotes an even parity bit
+	ParityEven
+
+	// ParityOdd denotes an odd parity bit
+	ParityOdd
+)
+
+// String returns the conventional abbreviation of the parity mode, fulfilling the Stringer interface
+func (p Parity) String() string {
+	switch p {
+	case ParityEven:
+		return "E"
+	case ParityOdd:
+		return "O"
+	}
+
+	return "N"
+}
+
+func (p Parity) serialMode() serial.ParityMode {
+	switch p {
+	case ParityEven:
+		return serial.PARITY_EVEN
+	case ParityOdd:
+		return serial.PARITY_ODD
+	}
+
+	return serial.PARITY_NONE
+}
+
+// LineSettings denotes the parity / stop bit settings of the serial line (the
+// number of data bits is always 8)
+type LineSettings struct {
+	Parity   Parity
+	StopBits uint
+}
+
+// String returns the conventional notation of the line settings (e.g. "8N1"),
+// fulfilling the Stringer interface
+func (l LineSettings) String() string {
+	return fmt.Sprintf("8%s%d", l.Parity, l.StopBits)
+}
+
+// DefaultLineSettings denotes the line settings of a genuine device (8N1)
+var DefaultLineSettings = LineSettings{
+	Parity:   ParityNone,
+	StopBits: 1,
+}
+
+// AlternativeLineSettings denotes the line settings attempted (in order) if the
+// default ones yield corrupt frames (see WithLineSettingsProbe())
+var AlternativeLineSettings = []LineSettings{
+	{Parity: ParityNone, StopBits: 2},
+	{Parity: ParityEven, StopBits: 1},
+	{Parity: ParityEven, StopBits: 2},
+	{Parity: ParityOdd, StopBits: 1},
+	{Parity: ParityOdd, StopBits: 2},
+}
+
+// LineSettings returns the line settings in use (which may differ from the default
+// ones if adopted by a probe, see WithLineSettingsProbe())
+func (s *SDS011) LineSettings() LineSettings {
+	s.portMutex.Lock()
+	defer s.portMutex.Unlock()
+
+	return s.lineSettings
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// probeLineSettings queries the firmware version using the current line settings
+// and, if only corrupt frames are received, probes the alternative line settings,
+// adopting the first one that yields a valid reply
+// NOTE: Only corrupt frames trigger the probe (a missing reply is not caused by
+// wrong line settings), the initial settings are restored if no alternative works
+func (s *SDS011) probeLineSettings() error {
+
+	for i := 0; i < lineProbeAttempts; i++ {
+		if _, err := s.GetFirmwareTimeout(detectTimeout); err == nil || !isCorruptFrameError(err) {
+			return nil
+		}
+	}
+
+	initial := s.lineSettings
+	for _, settings := range AlternativeLineSettings {
+		if err := s.reopenWithLineSettings(settings); err != nil {
+			return err
+		}
+		if _, err := s.GetFirmwareTimeout(detectTimeout); err == nil {
+			return nil
+		}
+	}
+
+	return s.reopenWithLineSettings(initial)
+}
+
+// reopenWithLineSettings re-opens the port using different line settings
+func (s *SDS011) reopenWithLineSettings(settings LineSettings) error {
+	s.portMutex.Lock()
+	defer s.portMutex.Unlock()
+
+	s.conn.close() // #nosec G104
+	s.lineSettings = settings
+
+	port, err := s.open()
+	if err != nil {
+		return fmt.Errorf("error re-opening port using line settings %s: %w", settings, err)
+	}
+	s.conn = s.newConnection(port, s.conn.ignoreEOF)
+
+	return nil
+}
@@ -0,0 +1,410 @@
+package sds011
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+
+	// MinSpinUp denotes the minimum recommended time for the device to settle
+	// after waking up
+	MinSpinUp = 15 * time.Second
+
+	// MaxSpinUp denotes the maximum recommended time for the device to settle
+	// after waking up
+	MaxSpinUp = 60 * time.Second
+)
+
+// reconnector denotes a sensor that can re-establish its connection
+type reconnector interface {
+	Reconnect() error
+}
+
+// forceQuerier denotes a sensor that can query data disregarding any cached state
+type forceQuerier interface {
+	ForceQuery(ctx context.Context) (*DataPoint, error)
+}
+
+// modeRefresher denotes a sensor that can query its modes disregarding any cached state
+type modeRefresher interface {
+	RefreshWorkMode() (WorkMode, error)
+	RefreshReportingMode() (ReportingMode, error)
+}
+
+// Health denotes the result of a health check (a healthy result may carry
+// details on a recovered condition, e.g. configuration drift)
+type Health struct {
+	OK      bool
+	Details string
+}
+
+// LoopConfig denotes the configuration of a measurement loop
+type LoopConfig struct {
+
+	// Open denotes the function used to open the sensor (and to re-open it if
+	// its connection cannot be re-established)
+	Open func() (Sensor, error)
+
+	// SpinUp denotes the time to wait for the fan / air flow to settle before
+	// taking a measurement (0 selects the recommended spin-up for the measurement
+	// delay, see RecommendedSpinUp())
+	SpinUp time.Duration
+
+	// MeasurementDelay denotes the time to wait between measurements
+	MeasurementDelay time.Duration
+
+	// WorkPeriod denotes the working period applied to the device at the start
+	// of each session (default: continuous operation, i.e. the duty cycle is solely
+	// controlled by the loop via MeasurementDelay, see SetWorkPeriod())
+	WorkPeriod int
+
+	// Jitter denotes the maximum random deviation from the measurement delay,
+	// avoiding synchronized measurements across many sensors (see NextInterval())
+	Jitter time.Duration
+
+	// Backoff denotes the time to wait before reconnecting the sensor after a
+	// failure (or loss of connection, see IsTransportError()) to allow the device
+	// to (re-)settle
+	Backoff time.Duration
+
+	// MaxFailures denotes the number of consecutive failed measurements after
+	// which the sensor is reconnected (0 disables reconnecting on failed measurements)
+	MaxFailures int
+
+	// ErrorRepeatInterval denotes the interval in which identical consecutive
+	// failures are reported (0 reports each failure): Repetitions in between are
+	// suppressed and summarized in the next report (see throttleHealth())
+	ErrorRepeatInterval time.Duration
+
+	// ModeCheckInterval denotes the interval in which the modes of the device are
+	// re-read in between measurements (0 disables the check): If the device has
+	// reverted to its defaults (e.g. after a brownout / power cycle), the expected
+	// modes are re-applied and the drift is reported via the health callback (see
+	// checkModeDrift())
+	ModeCheckInterval time.Duration
+}
+
+// DefaultLoopConfig returns a loop configuration populated with sane defaults
+// for a sensor at the provided path
+func DefaultLoopConfig(path string) LoopConfig {
+	return LoopConfig{
+		Open: func() (Sensor, error) {
+			return New(path)
+		},
+		SpinUp:              30 * time.Second,
+		MeasurementDelay:    5 * time.Minute,
+		Backoff:             10 * time.Second,
+		MaxFailures:         3,
+		ErrorRepeatInterval: 10 * time.Minute,
+	}
+}
+
+// RunLoop continuously performs measurements until the context is cancelled:
+// The device is woken up, given time to settle, queried and put back to sleep
+// in order to conserve lifetime of the laser. Panics and failures are recovered
+// from by re-establishing the connection after a backoff period. All data points
+// and the health after each step are reported via the provided callbacks (either
+// of which may be nil).
+// NOTE: The sensor is kept open across measurements and, after a failure, only
+// its connection is re-established (if supported, see SDS011.Reconnect()) since
+// repeatedly opening the device needlessly re-triggers udev / the USB adapter
+// and loses the state of the sensor object (e.g. metrics). The sensor is only
+// re-opened via cfg.Open if reconnecting fails.
+func RunLoop(ctx context.Context, cfg LoopConfig, onData func(*DataPoint), onHealth func(Health)) error {
+
+	if cfg.Open == nil {
+		return fmt.Errorf("no function to open the sensor provided")
+	}
+	if onData == nil {
+		onData = func(*DataPoint) {}
+	}
+	if onHealth == nil {
+		onHealth = func(Health) {}
+	}
+	if cfg.ErrorRepeatInterval > 0 {
+		onHealth = throttleHealth(onHealth, cfg.ErrorRepeatInterval)
+	}
+
+	// Ensure that the sensor is put in sleep mode after termination to conserve
+	// lifetime of the laser
+	var sensor Sensor
+	defer func() {
+		if sensor != nil {
+			sensor.Shutdown() // #nosec G104
+		}
+	}()
+
+	for {
+		var err error
+		if sensor == nil {
+			if sensor, err = cfg.Open(); err != nil {
+				sensor = nil
+			}
+		}
+		if err == nil {
+			err = runSession(ctx, cfg, sensor, onData, onHealth)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		onHealth(Health{
+			OK:      false,
+			Details: err.Error(),
+		})
+
+		// Back off to allow device to (re-)settle
+		if err := sleepContext(ctx, cfg.Backoff); err != nil {
+			return err
+		}
+
+		// Attempt to re-establish the connection of the existing sensor, falling
+		// back to re-opening it
+		if sensor != nil {
+			if r, ok := sensor.(reconnector); ok && r.Reconnect() == nil {
+				continue
+			}
+			sensor.Shutdown() // #nosec G104
+			sensor = nil
+		}
+	}
+}
+
+// throttleHealth wraps a health callback, suppressing identical consecutive
+// failures: A failure is reported once, repetitions are only reported once per
+// interval (summarizing the number of occurrences). Any other report (healthy
+// or a different failure) is passed on immediately.
+func throttleHealth(onHealth func(Health), interval time.Duration) func(Health) {
+
+	var (
+		last       Health
+		lastReport time.Time
+		suppressed int
+	)
+
+	return func(h Health) {
+		if !h.OK && !last.OK && h.Details == last.Details && !lastReport.IsZero() {
+			if time.Since(lastReport) < interval {
+				suppressed++
+				return
+			}
+
+			onHealth(Health{
+				OK:      false,
+				Details: fmt.Sprintf("still failing (%d repetition(s) since %s): %s", suppressed+1, lastReport.Format(time.RFC3339), h.Details),
+			})
+			lastReport, suppressed = time.Now(), 0
+			return
+		}
+
+		onHealth(h)
+		last, lastReport, suppressed = h, time.Now(), 0
+	}
+}
+
+// RecommendedSpinUp computes a recommended time for the device to settle after
+// waking up, depending on the time it was asleep (period): Starting from MinSpinUp,
+// one additional second is added per minute of sleep, bounded by MaxSpinUp
+// NOTE: This is a heuristic, the longer the fan was off, the longer it takes
+// to establish a stable air flow through the measurement chamber
+func RecommendedSpinUp(period time.Duration) time.Duration {
+	spinUp := MinSpinUp + time.Duration(period.Minutes()*float64(time.Second))
+	if spinUp < MinSpinUp {
+		return MinSpinUp
+	}
+	if spinUp > MaxSpinUp {
+		return MaxSpinUp
+	}
+
+	return spinUp
+}
+
+// runSession performs measurements until a failure occurs or the context is
+// cancelled
+func runSession(ctx context.Context, cfg LoopConfig, sensor Sensor, onData func(*DataPoint), onHealth func(Health)) (err error) {
+
+	// Recover from potential panic when reading from device
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic recovered in measurement loop: %v", r)
+		}
+	}()
+
+	// Ensure that device is active, then enable query mode
+	if err := sensor.SetWorkModeContext(ctx, WorkModeActive); err != nil {
+		return fmt.Errorf("error setting active mode: %w", err)
+	}
+	if err := sensor.SetReportingModeContext(ctx, ReportingModeQuery); err != nil {
+		return fmt.Errorf("error setting query reporting mode: %w", err)
+	}
+	if err := sensor.SetWorkPeriodContext(ctx, cfg.WorkPeriod); err != nil {
+		return fmt.Errorf("error setting working period: %w", err)
+	}
+
+	spinUp := cfg.SpinUp
+	if spinUp == 0 {
+		spinUp = RecommendedSpinUp(cfg.MeasurementDelay)
+	}
+
+	failures := 0
+	for {
+		dataPoint, err := measure(ctx, sensor, spinUp, failures > 0)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if dataPoint != nil {
+			onData(dataPoint)
+		}
+		if err != nil {
+			onHealth(Health{
+				OK:      false,
+				Details: err.Error(),
+			})
+
+			// A broken link cannot be recovered from by retrying, re-establish
+			// the connection right away
+			if IsTransportError(err) {
+				return fmt.Errorf("lost connection to sensor: %w", err)
+			}
+			if failures++; cfg.MaxFailures > 0 && failures >= cfg.MaxFailures {
+				return fmt.Errorf("%d consecutive failed measurements, last error: %w", failures, err)
+			}
+		} else {
+			failures = 0
+			onHealth(Health{
+				OK: true,
+			})
+		}
+
+		// Wait to perform the next measurement (checking for configuration drift
+		// in the meantime, if enabled)
+		if err := waitForMeasurement(ctx, cfg, sensor, onHealth); err != nil {
+			return err
+		}
+	}
+}
+
+// waitForMeasurement waits for the (jittered) measurement delay, checking the
+// modes of the device in the configured interval
+func waitForMeasurement(ctx context.Context, cfg LoopConfig, sensor Sensor, onHealth func(Health)) error {
+
+	delay := NextInterval(cfg.MeasurementDelay, cfg.Jitter)
+	if cfg.ModeCheckInterval <= 0 {
+		return sleepContext(ctx, delay)
+	}
+
+	deadline := time.Now().Add(delay)
+	for {
+		remaining := time.Until(deadline)
+		if remaining <= cfg.ModeCheckInterval {
+			return sleepContext(ctx, remaining)
+		}
+		if err := sleepContext(ctx, cfg.ModeCheckInterval); err != nil {
+			return err
+		}
+
+		if err := checkModeDrift(ctx, sensor, onHealth); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			onHealth(Health{
+				OK:      false,
+				Details: err.Error(),
+			})
+
+			// A broken link cannot be recovered from by retrying, re-establish
+			// the connection right away
+			if IsTransportError(err) {
+				return fmt.Errorf("lost connection to sensor: %w", err)
+			}
+		}
+	}
+}
+
+// checkModeDrift re-reads the work mode of the device (which is expected to be
+// asleep in between measurements) and, if it is awake (e.g. after a reset), its
+// reporting mode, re-applying the expected modes and reporting the drift via the
+// health callback
+// NOTE: The modes are read from the device directly (if supported, see
+// SDS011.RefreshWorkMode()) since cached modes cannot reflect a reset of the
+// device. A timeout is considered a device in sleep mode (which may not reply).
+func checkModeDrift(ctx context.Context, sensor Sensor, onHealth func(Health)) error {
+
+	getWorkMode, getReportingMode := sensor.GetWorkMode, sensor.GetReportingMode
+	if r, ok := sensor.(modeRefresher); ok {
+		getWorkMode, getReportingMode = r.RefreshWorkMode, r.RefreshReportingMode
+	}
+
+	workMode, err := getWorkMode()
+	if err != nil {
+		if errors.Is(err, ErrTimeout) {
+			return nil
+		}
+		return fmt.Errorf("error checking work mode: %w", err)
+	}
+	if workMode == WorkModeSleep {
+		return nil
+	}
+	reportingMode, err := getReportingMode()
+	if err != nil {
+		return fmt.Errorf("error checking reporting mode: %w", err)
+	}
+
+	// Re-apply the expected modes (while the device is still awake), then put
+	// it back to sleep to conserve lifetime of the laser
+	if reportingMode != ReportingModeQuery {
+		if err := sensor.SetReportingModeContext(ctx, ReportingModeQuery); err != nil {
+			return fmt.Errorf("error re-applying query reporting mode: %w", err)
+		}
+	}
+	if err := sensor.SetWorkModeContext(ctx, WorkModeSleep); err != nil {
+		return fmt.Errorf("error re-applying sleep mode: %w", err)
+	}
+
+	onHealth(Health{
+		OK:      true,
+		Details: fmt.Sprintf("configuration drift detected (work mode: %s, reporting mode: %s), possibly due to a reset of the device, re-applied expected modes", workMode, reportingMode),
+	})
+
+	return nil
+}
+
+// measure wakes the device, waits for it to settle, queries a single data point
+// and puts it back to sleep (a data point may be returned alongside an error if
+// the device could not be put back to sleep). If requested (e.g. after a failed
+// measurement), the data is queried via ForceQuery() (if supported).
+func measure(ctx context.Context, sensor Sensor, spinUp time.Duration, force bool) (*DataPoint, error) {
+
+	// Activate laser and fan, then wait for the device to settle and for stable
+	// air flow
+	if err := sensor.SetWorkModeContext(ctx, WorkModeActive); err != nil {
+		return nil, fmt.Errorf("error setting active mode: %w", err)
+	}
+	if err := sleepContext(ctx, spinUp); err != nil {
+		return nil, err
+	}
+
+	// Read single data point
+	var (
+		dataPoint *DataPoint
+		queryErr  error
+	)
+	if fq, ok := sensor.(forceQuerier); ok && force {
+		dataPoint, queryErr = fq.ForceQuery(ctx)
+	} else {
+		dataPoint, queryErr = sensor.QueryDataContext(ctx)
+	}
+	if queryErr != nil {
+		queryErr = fmt.Errorf("error reading data: %w", queryErr)
+	}
+
+	// Put sensor to sleep mode (if the context was cancelled in the meantime,
+	// this is taken care of by the shutdown of the sensor, see RunLoop())
+	if err := sensor.SetWorkModeContext(ctx, WorkModeSleep); err != nil && queryErr == nil {
+		return dataPoint, fmt.Errorf("error setting sleep mode: %w", err)
+	}
+
+	return dataPoint, queryErr
+}
@@ -0,0 +1,202 @@
+package sds011
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRunLoopSimulatedSensor(t *testing.T) {
+
+	var (
+		sensors    []*SimulatedSensor
+		dataPoints []*DataPoint
+		health     []Health
+		mutex      sync.Mutex
+	)
+
+	// Fail to open the sensor once to exercise the backoff / re-open path
+	cfg := LoopConfig{
+		Open: func() (Sensor, error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if len(health) == 0 {
+				return nil, errors.New("device not found")
+			}
+			s := newTestSimulatedSensor()
+			sensors = append(sensors, s)
+			return s, nil
+		},
+		SpinUp:           time.Millisecond,
+		MeasurementDelay: 5 * time.Millisecond,
+		Backoff:          time.Millisecond,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	err := RunLoop(ctx, cfg, func(p *DataPoint) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		dataPoints = append(dataPoints, p)
+	}, func(h Health) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		health = append(health, h)
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("unexpected loop termination, want %v, have %v", context.DeadlineExceeded, err)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if len(sensors) != 1 {
+		t.Fatalf("unexpected number of opened sensors, want 1, have %d", len(sensors))
+	}
+	if len(dataPoints) < 3 {
+		t.Fatalf("too few data points, want at least 3, have %d", len(dataPoints))
+	}
+	if health[0].OK || health[0].Details != "device not found" {
+		t.Fatalf("unexpected initial health report: %v", health[0])
+	}
+	for _, h := range health[1:] {
+		if !h.OK {
+			t.Fatalf("unexpected unhealthy report: %v", h)
+		}
+	}
+
+	// The sensor must be left asleep and closed after termination
+	s := sensors[0]
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.workMode != WorkModeSleep || !s.isClosed {
+		t.Fatalf("sensor not shut down after termination (work mode: %s, closed: %v)", s.workMode, s.isClosed)
+	}
+}
+
+func TestRunLoopModeDrift(t *testing.T) {
+
+	var (
+		sensor = newTestSimulatedSensor()
+		drift  []Health
+		mutex  sync.Mutex
+	)
+
+	cfg := LoopConfig{
+		Open: func() (Sensor, error) {
+			return sensor, nil
+		},
+		SpinUp:            time.Millisecond,
+		MeasurementDelay:  100 * time.Millisecond,
+		ModeCheckInterval: 10 * time.Millisecond,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error)
+	go func() {
+		done <- RunLoop(ctx, cfg, nil, func(h Health) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			if h.OK && h.Details != "" {
+				drift = append(drift, h)
+				cancel()
+			}
+		})
+	}()
+
+	// Simulate a reset of the device to its factory defaults in between
+	// measurements
+	time.Sleep(30 * time.Millisecond)
+	sensor.mutex.Lock()
+	sensor.workMode, sensor.reportingMode = WorkModeActive, ReportingModeActive
+	sensor.mutex.Unlock()
+
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("unexpected loop termination, want %v, have %v", context.Canceled, err)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(drift) != 1 {
+		t.Fatalf("unexpected number of drift reports, want 1, have %d", len(drift))
+	}
+}
+
+func TestRunLoopCancelHungCommand(t *testing.T) {
+
+	d := newMockDevice()
+	d.muted = true
+	s := newMockSensor(t, d, WithTimeout(5*time.Second))
+
+	cfg := LoopConfig{
+		Open: func() (Sensor, error) {
+			return s, nil
+		},
+		SpinUp:           time.Millisecond,
+		MeasurementDelay: time.Second,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error)
+	go func() {
+		done <- RunLoop(ctx, cfg, nil, nil)
+	}()
+
+	// The loop is stuck waiting for a reply to its initial configuration command
+	// when it is cancelled (the device recovers in order to be put to sleep on
+	// shutdown)
+	time.Sleep(100 * time.Millisecond)
+	d.mutex.Lock()
+	d.muted = false
+	d.mutex.Unlock()
+
+	start := time.Now()
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("unexpected loop termination, want %v, have %v", context.Canceled, err)
+		}
+		if latency := time.Since(start); latency > 250*time.Millisecond {
+			t.Fatalf("loop terminated %v after cancellation, want less than 250ms", latency)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("loop did not terminate after cancellation")
+	}
+}
+
+func TestRunLoopWorkPeriod(t *testing.T) {
+
+	s := newTestSimulatedSensor()
+	cfg := LoopConfig{
+		Open: func() (Sensor, error) {
+			return s, nil
+		},
+		SpinUp:           time.Millisecond,
+		MeasurementDelay: time.Second,
+		WorkPeriod:       5,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := RunLoop(ctx, cfg, func(*DataPoint) {
+		cancel()
+	}, nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("unexpected loop termination, want %v, have %v", context.Canceled, err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.workPeriod != cfg.WorkPeriod {
+		t.Fatalf("unexpected working period, want %d, have %d", cfg.WorkPeriod, s.workPeriod)
+	}
+}
@@ -0,0 +1,44 @@
+package sds011
+
+import "sync"
+
+// Metrics denotes a snapshot of cumulative counters reflecting the quality of
+// the serial link to the device
+// All counters accumulate over the lifetime of the SDS011 object (including
+// reconnects) and are only ever reset by an explicit call to ResetMetrics()
+type Metrics struct {
+	Name string // Name of the sensor (see Name()), identifying the snapshot
+
+	Reads            uint64 // Number of attempted frame reads
+	Timeouts         uint64 // Number of reads that timed out
+	ChecksumFailures uint64 // Number of frames with invalid checksum
+	Resyncs          uint64 // Number of attempts to re-establish frame alignment
+	Reconnects       uint64 // Number of reconnects to the device
+}
+
+type metrics struct {
+	Metrics
+	sync.Mutex
+}
+
+func (m *metrics) add(fn func(*Metrics)) {
+	m.Lock()
+	fn(&m.Metrics)
+	m.Unlock()
+}
+
+// Metrics returns a snapshot of the current link quality counters
+func (s *SDS011) Metrics() Metrics {
+	s.metrics.Lock()
+	defer s.metrics.Unlock()
+
+	res := s.metrics.Metrics
+	res.Name = s.Name()
+
+	return res
+}
+
+// ResetMetrics resets all link quality counters to zero
+func (s *SDS011) ResetMetrics() {
+	s.metrics.add(func(m *Metrics) { *m = Metrics{} })
+}
@@ -0,0 +1,241 @@
+package sds011
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockDeviceID denotes the ID reported by a mock device
+const mockDeviceID = DeviceID(0xa160)
+
+// mockDevice emulates the serial protocol of an SDS011 on the device end of an
+// in-memory connection: A sleeping device only answers work mode changes, in
+// active reporting mode an awake device emits a data frame per frameInterval
+type mockDevice struct {
+	awake         bool
+	muted         bool             // Do not answer any command (emulating a hung device)
+	ignored       map[Command]bool // Commands not answered (e.g. emulating lost replies)
+	activeReports bool
+	workPeriod    byte
+	firmware      [3]byte // Firmware version (year, month, day)
+	count25       uint16
+	count10       uint16
+	samples       [][2]uint16 // Counts reported by subsequent data queries (before falling back to count25 / count10)
+
+	frameInterval time.Duration   // Interval between data frames in active reporting mode (0: none)
+	onConnect     func(io.Writer) // Invoked on each new connection before any command is served
+	commands      []time.Time     // Reception times of all commands
+	conns         []net.Conn      // Device ends of all connections
+	writeMutex    map[net.Conn]*sync.Mutex
+	mutex         sync.Mutex
+}
+
+// newMockDevice creates a new mock device (awake, in query reporting mode)
+func newMockDevice() *mockDevice {
+	return &mockDevice{
+		awake:      true,
+		firmware:   [3]byte{18, 11, 16},
+		count25:    123,
+		count10:    456,
+		writeMutex: make(map[net.Conn]*sync.Mutex),
+	}
+}
+
+// newMockSensor creates a sensor connected to the mock device (reconnecting
+// opens a new connection to the same device)
+func newMockSensor(t testing.TB, d *mockDevice, opts ...Option) *SDS011 {
+	t.Helper()
+
+	s := newSDS011("mock", opts...)
+	s.open = d.open
+	port, err := s.open()
+	if err != nil {
+		t.Fatalf("error opening mock device: %s", err)
+	}
+	s.conn = s.newConnection(port, false)
+	t.Cleanup(func() {
+		s.Close() // #nosec G104
+		d.close()
+	})
+
+	return s
+}
+
+func TestMockDeviceTailInPayload(t *testing.T) {
+
+	// Report counts encoding the tail byte in the payload (171 = 0xab), which
+	// must not split the frame
+	d := newMockDevice()
+	d.count25, d.count10 = 171, 0x1aab
+	s := newMockSensor(t, d)
+
+	for i := 0; i < 3; i++ {
+		dp, err := s.QueryData()
+		if err != nil {
+			t.Fatalf("error querying data: %s", err)
+		}
+		if math.Abs(dp.PM25-17.1) > 1e-9 || math.Abs(dp.PM10-682.7) > 1e-9 {
+			t.Fatalf("unexpected data point, want 17.1 / 682.7, have %v / %v", dp.PM25, dp.PM10)
+		}
+	}
+}
+
+// open establishes a new connection to the device
+func (d *mockDevice) open() (io.ReadWriteCloser, error) {
+	host, dev := net.Pipe()
+
+	d.mutex.Lock()
+	d.conns = append(d.conns, dev)
+	d.writeMutex[dev] = &sync.Mutex{}
+	d.mutex.Unlock()
+
+	if d.onConnect != nil {
+		go d.onConnect(dev)
+	}
+	go d.serve(dev)
+	if d.frameInterval > 0 {
+		go d.report(dev)
+	}
+
+	return host, nil
+}
+
+// close closes all connections to the device
+func (d *mockDevice) close() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	for _, conn := range d.conns {
+		conn.Close() // #nosec G104
+	}
+}
+
+// commandTimes returns the reception times of all commands received so far
+func (d *mockDevice) commandTimes() []time.Time {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	return append([]time.Time(nil), d.commands...)
+}
+
+// serve answers all commands received on a connection until it is closed
+func (d *mockDevice) serve(conn net.Conn) {
+	buf := make([]byte, commandLen)
+	for {
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			return
+		}
+		if reply := d.handle(buf); reply != nil {
+			d.write(conn, reply)
+		}
+	}
+}
+
+// report emits data frames while the device is awake and in active reporting mode
+func (d *mockDevice) report(conn net.Conn) {
+	ticker := time.NewTicker(d.frameInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		d.mutex.Lock()
+		frame := mockDataFrame(d.count25, d.count10)
+		active := d.awake && d.activeReports
+		d.mutex.Unlock()
+
+		if active {
+			if err := d.write(conn, frame); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (d *mockDevice) write(conn net.Conn, data []byte) error {
+	d.mutex.Lock()
+	mutex := d.writeMutex[conn]
+	d.mutex.Unlock()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	_, err := conn.Write(data)
+	return err
+}
+
+// handle processes a single command, returning the reply (if any)
+func (d *mockDevice) handle(cmd []byte) []byte {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.commands = append(d.commands, time.Now())
+	if cmd[0] != packetHeader || cmd[1] != commandHeader || cmd[commandLen-1] != packetTail ||
+		calcChecksum(cmd[2:17]) != cmd[17] {
+		return nil
+	}
+
+	command, set, value := Command(cmd[2]), cmd[3] == commandSet, cmd[4]
+	if d.muted || d.ignored[command] || (!d.awake && command != CommandSleepWork) {
+		return nil
+	}
+
+	switch command {
+	case CommandQueryData:
+		if len(d.samples) > 0 {
+			sample := d.samples[0]
+			d.samples = d.samples[1:]
+			return mockDataFrame(sample[0], sample[1])
+		}
+		return mockDataFrame(d.count25, d.count10)
+	case CommandSleepWork:
+		if set {
+			d.awake = value == 1
+		} else if !d.awake {
+			return nil
+		}
+		return mockReply(command, cmd[3], boolByte(d.awake), 0)
+	case CommandReportingMode:
+		if set {
+			d.activeReports = value == 0
+		}
+		return mockReply(command, cmd[3], boolByte(!d.activeReports), 0)
+	case CommandWorkingPeriod:
+		if set {
+			d.workPeriod = value
+		}
+		return mockReply(command, cmd[3], d.workPeriod, 0)
+	case CommandFirmware:
+		return mockReply(command, d.firmware[0], d.firmware[1], d.firmware[2])
+	}
+
+	return nil
+}
+
+// mockDataFrame creates a data frame carrying the provided counts
+func mockDataFrame(count25, count10 uint16) []byte {
+	frame := []byte{packetHeader, byte(PacketKindData), 0, 0, 0, 0, byte(mockDeviceID >> 8), byte(mockDeviceID & 0xff), 0, packetTail}
+	binary.LittleEndian.PutUint16(frame[2:4], count25)
+	binary.LittleEndian.PutUint16(frame[4:6], count10)
+	frame[8] = calcChecksum(frame[2:8])
+
+	return frame
+}
+
+// mockReply creates a reply frame to a command carrying the provided data bytes
+func mockReply(cmd Command, b1, b2, b3 byte) []byte {
+	frame := []byte{packetHeader, byte(PacketKindReply), byte(cmd), b1, b2, b3, byte(mockDeviceID >> 8), byte(mockDeviceID & 0xff), 0, packetTail}
+	frame[8] = calcChecksum(frame[2:8])
+
+	return frame
+}
+
+func boolByte(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,81 @@
+package sds011
+
+import "sync"
+
+// modeCache keeps track of the last known work / reporting mode, working
+// period and ID of the device (an empty mode denotes an unknown state)
+type modeCache struct {
+	workMode        WorkMode
+	reportingMode   ReportingMode
+	workPeriod      int
+	workPeriodKnown bool
+	deviceID        DeviceID
+	deviceIDKnown   bool
+
+	sync.Mutex
+}
+
+func (s *SDS011) cachedWorkMode() (WorkMode, bool) {
+	s.modeCache.Lock()
+	defer s.modeCache.Unlock()
+
+	return s.modeCache.workMode, s.modeCache.workMode != ""
+}
+
+func (s *SDS011) setCachedWorkMode(mode WorkMode) {
+	s.modeCache.Lock()
+	defer s.modeCache.Unlock()
+
+	s.modeCache.workMode = mode
+}
+
+func (s *SDS011) cachedReportingMode() (ReportingMode, bool) {
+	s.modeCache.Lock()
+	defer s.modeCache.Unlock()
+
+	return s.modeCache.reportingMode, s.modeCache.reportingMode != ""
+}
+
+func (s *SDS011) setCachedReportingMode(mode ReportingMode) {
+	s.modeCache.Lock()
+	defer s.modeCache.Unlock()
+
+	s.modeCache.reportingMode = mode
+}
+
+func (s *SDS011) cachedWorkPeriod() (int, bool) {
+	s.modeCache.Lock()
+	defer s.modeCache.Unlock()
+
+	return s.modeCache.workPeriod, s.modeCache.workPeriodKnown
+}
+
+func (s *SDS011) setCachedWorkPeriod(delayMinutes int) {
+	s.modeCache.Lock()
+	defer s.modeCache.Unlock()
+
+	s.modeCache.workPeriod, s.modeCache.workPeriodKnown = delayMinutes, true
+}
+
+func (s *SDS011) cachedDeviceID() (DeviceID, bool) {
+	s.modeCache.Lock()
+	defer s.modeCache.Unlock()
+
+	return s.modeCache.deviceID, s.modeCache.deviceIDKnown
+}
+
+func (s *SDS011) setCachedDeviceID(id DeviceID) {
+	s.modeCache.Lock()
+	defer s.modeCache.Unlock()
+
+	s.modeCache.deviceID, s.modeCache.deviceIDKnown = id, true
+}
+
+// invalidateModeCache resets the cache to an unknown state (e.g. after a reconnect)
+func (s *SDS011) invalidateModeCache() {
+	s.modeCache.Lock()
+	defer s.modeCache.Unlock()
+
+	s.modeCache.workMode, s.modeCache.reportingMode = "", ""
+	s.modeCache.workPeriodKnown, s.modeCache.deviceIDKnown = false, false
+}
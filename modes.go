@@ -0,0 +1,106 @@
+package sds011
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// ParseWorkMode parses a working mode from either its human-readable name
+// ("sleep" / "active") or its raw hex code ("00" / "01")
+func ParseWorkMode(s string) (WorkMode, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "sleep", string(WorkModeSleep):
+		return WorkModeSleep, nil
+	case "active", string(WorkModeActive):
+		return WorkModeActive, nil
+	}
+
+	return "", fmt.Errorf("invalid work mode `%s`, must be one of sleep / active (or 00 / 01)", s)
+}
+
+// String returns the human-readable name of the working mode, fulfilling the Stringer interface
+func (m WorkMode) String() string {
+	switch m {
+	case WorkModeSleep:
+		return "sleep"
+	case WorkModeActive:
+		return "active"
+	}
+
+	return fmt.Sprintf("unknown (%s)", string(m))
+}
+
+// ParseReportingMode parses a reporting mode from either its human-readable name
+// ("active" / "query") or its raw hex code ("00" / "01")
+func ParseReportingMode(s string) (ReportingMode, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "active", string(ReportingModeActive):
+		return ReportingModeActive, nil
+	case "query", string(ReportingModeQuery):
+		return ReportingModeQuery, nil
+	}
+
+	return "", fmt.Errorf("invalid reporting mode `%s`, must be one of active / query (or 00 / 01)", s)
+}
+
+// String returns the human-readable name of the reporting mode, fulfilling the Stringer interface
+func (m ReportingMode) String() string {
+	switch m {
+	case ReportingModeActive:
+		return "active"
+	case ReportingModeQuery:
+		return "query"
+	}
+
+	return fmt.Sprintf("unknown (%s)", string(m))
+}
+
+// ActiveModePolicy denotes how QueryData() behaves if the device is known to be
+// in active reporting mode (see WithActiveModePolicy())
+type ActiveModePolicy int
+
+const (
+
+	// ActiveModePassthrough returns the next data frame reported by the device
+	// instead of sending a query command (default)
+	ActiveModePassthrough ActiveModePolicy = iota
+
+	// ActiveModeError returns ErrActiveReportingMode without reading any data
+	ActiveModeError
+
+	// ActiveModeAutoSwitch temporarily switches the device to query reporting
+	// mode, queries data and restores active reporting mode afterwards
+	ActiveModeAutoSwitch
+)
+
+// DecodeWorkMode interprets the mode byte of a work mode reply
+func DecodeWorkMode(b byte) WorkMode {
+	return WorkMode(hex.EncodeToString([]byte{b}))
+}
+
+// EncodeWorkMode returns the mode byte representing a work mode (as used in the
+// respective command / reply)
+func EncodeWorkMode(m WorkMode) (byte, error) {
+	return encodeMode(string(m))
+}
+
+// DecodeReportingMode interprets the mode byte of a reporting mode reply
+func DecodeReportingMode(b byte) ReportingMode {
+	return ReportingMode(hex.EncodeToString([]byte{b}))
+}
+
+// EncodeReportingMode returns the mode byte representing a reporting mode (as
+// used in the respective command / reply)
+func EncodeReportingMode(m ReportingMode) (byte, error) {
+	return encodeMode(string(m))
+}
+
+func encodeMode(mode string) (byte, error) {
+	b, err := hex.DecodeString(mode)
+	if err != nil || len(b) != 1 {
+		return 0, fmt.Errorf("invalid mode `%s`, must be a single hex-encoded byte", mode)
+	}
+
+	return b[0], nil
+}
@@ -0,0 +1,246 @@
+package sds011
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// NDJSONWriter denotes a Sink that writes one JSON encoded data point per line
+// (newline-delimited JSON) to an io.Writer
+// Output is buffered and flushed periodically (if requested) as well as on Close
+type NDJSONWriter struct {
+	w       io.Writer
+	buf     *bufio.Writer
+	encoder *json.Encoder
+	mutex   sync.Mutex
+
+	done     chan struct{}
+	wg       sync.WaitGroup
+	isClosed bool
+}
+
+// NewNDJSONWriter creates a new NDJSONWriter writing to the provided writer,
+// flushing the buffered output every flushInterval (0 disables periodic flushes)
+func NewNDJSONWriter(w io.Writer, flushInterval time.Duration) *NDJSONWriter {
+	buf := bufio.NewWriter(w)
+	n := &NDJSONWriter{
+		w:       w,
+		buf:     buf,
+		encoder: json.NewEncoder(buf),
+		done:    make(chan struct{}),
+	}
+
+	if flushInterval > 0 {
+		n.wg.Add(1)
+		go n.flushLoop(flushInterval)
+	}
+
+	return n
+}
+
+// NewGzipNDJSONWriter creates a new NDJSONWriter writing to gzip compressed
+// files (see NewRotatingGzipFile for details on naming and rotation)
+func NewGzipNDJSONWriter(basePath string, maxSize int64, flushInterval time.Duration) (*NDJSONWriter, error) {
+	f, err := NewRotatingGzipFile(basePath, maxSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewNDJSONWriter(f, flushInterval), nil
+}
+
+// Write outputs a single data point as JSON line
+func (n *NDJSONWriter) Write(p DataPoint) error {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	if n.isClosed {
+		return fmt.Errorf("cannot write to closed NDJSON writer")
+	}
+
+	return n.encoder.Encode(p)
+}
+
+// Flush writes all buffered output to the underlying writer (flushing it as
+// well if it supports it)
+func (n *NDJSONWriter) Flush() error {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	return n.flush()
+}
+
+// Close flushes all buffered output and closes the underlying writer (if it
+// supports it)
+func (n *NDJSONWriter) Close() error {
+	n.mutex.Lock()
+	if n.isClosed {
+		n.mutex.Unlock()
+		return nil
+	}
+	n.isClosed = true
+	close(n.done)
+	n.mutex.Unlock()
+
+	n.wg.Wait()
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	var errs MultiError
+	if err := n.flush(); err != nil {
+		errs = append(errs, err)
+	}
+	if closer, ok := n.w.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs.ErrorOrNil()
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func (n *NDJSONWriter) flushLoop(interval time.Duration) {
+	defer n.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			n.Flush() // #nosec G104
+		case <-n.done:
+			return
+		}
+	}
+}
+
+type flusher interface {
+	Flush() error
+}
+
+func (n *NDJSONWriter) flush() error {
+	if err := n.buf.Flush(); err != nil {
+		return err
+	}
+	if f, ok := n.w.(flusher); ok {
+		return f.Flush()
+	}
+
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// RotatingGzipFile denotes an io.WriteCloser writing gzip compressed data to a
+// sequence of files, starting a new file once a size limit is exceeded
+type RotatingGzipFile struct {
+	basePath string
+	maxSize  int64
+
+	file     *os.File
+	gz       *gzip.Writer
+	size     int64
+	lastByte byte
+}
+
+// NewRotatingGzipFile creates a new RotatingGzipFile, writing to files named
+// <basePath>-<timestamp>.gz and rotating once maxSize (uncompressed) bytes have
+// been written to the current file (0 disables rotation)
+// Rotation only ever occurs after a newline to keep line-based content intact
+func NewRotatingGzipFile(basePath string, maxSize int64) (*RotatingGzipFile, error) {
+	r := &RotatingGzipFile{
+		basePath: basePath,
+		maxSize:  maxSize,
+	}
+
+	if err := r.rotate(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Write writes compressed data to the current file, fulfilling the io.Writer interface
+// Data is split after the line reaching the size limit (if any), such that files
+// are rotated on line boundaries regardless of how the data is chunked by the caller
+func (r *RotatingGzipFile) Write(data []byte) (int, error) {
+	written := 0
+	for len(data) > 0 {
+		if r.maxSize > 0 && r.size >= r.maxSize && r.lastByte == '\n' {
+			if err := r.rotate(); err != nil {
+				return written, err
+			}
+		}
+
+		chunk := data
+		if r.maxSize > 0 {
+			start := r.maxSize - r.size - 1
+			if start < 0 {
+				start = 0
+			}
+			if start < int64(len(data)) {
+				if i := bytes.IndexByte(data[start:], '\n'); i >= 0 {
+					chunk = data[:start+int64(i)+1]
+				}
+			}
+		}
+
+		n, err := r.gz.Write(chunk)
+		r.size += int64(n)
+		written += n
+		if n > 0 {
+			r.lastByte = chunk[n-1]
+		}
+		if err != nil {
+			return written, err
+		}
+		data = data[n:]
+	}
+
+	return written, nil
+}
+
+// Flush flushes all pending compressed data to the current file
+func (r *RotatingGzipFile) Flush() error {
+	return r.gz.Flush()
+}
+
+// Close finalizes and closes the current file, fulfilling the io.Closer interface
+func (r *RotatingGzipFile) Close() error {
+	if err := r.gz.Close(); err != nil {
+		r.file.Close() // #nosec G104
+		return err
+	}
+
+	return r.file.Close()
+}
+
+func (r *RotatingGzipFile) rotate() error {
+	if r.file != nil {
+		if err := r.Close(); err != nil {
+			return fmt.Errorf("error closing %s: %w", r.file.Name(), err)
+		}
+	}
+
+	path := fmt.Sprintf("%s-%s.gz", r.basePath, time.Now().UTC().Format("20060102T150405.000000000"))
+	file, err := os.OpenFile(filepath.Clean(path), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("error creating %s: %w", path, err)
+	}
+
+	r.file, r.gz, r.size = file, gzip.NewWriter(file), 0
+
+	return nil
+}
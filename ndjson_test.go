@@ -0,0 +1,95 @@
+package sds011
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGzipNDJSONWriterRotation(t *testing.T) {
+
+	const (
+		maxSize = 1024
+		nPoints = 200
+	)
+
+	basePath := filepath.Join(t.TempDir(), "data")
+	w, err := NewGzipNDJSONWriter(basePath, maxSize, 0)
+	if err != nil {
+		t.Fatalf("error creating NDJSON writer: %s", err)
+	}
+
+	// Write several times the size limit (the buffered output is handed over in
+	// chunks not aligned to line boundaries)
+	ts := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < nPoints; i++ {
+		if err := w.Write(DataPoint{TimeStamp: ts.Add(time.Duration(i) * time.Second), PM25: float64(i), PM10: 2 * float64(i)}); err != nil {
+			t.Fatalf("error writing data point: %s", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("error closing NDJSON writer: %s", err)
+	}
+
+	files, err := filepath.Glob(basePath + "-*.gz")
+	if err != nil {
+		t.Fatalf("error listing rotated files: %s", err)
+	}
+	if len(files) < 2 {
+		t.Fatalf("unexpected number of files, want at least 2, have %d", len(files))
+	}
+
+	// Each file exceeds the size limit by at most one line and contains complete
+	// lines only
+	total := 0
+	for _, file := range files {
+		size, lines, maxLine := readGzipLines(t, file)
+		if size-maxLine >= maxSize {
+			t.Fatalf("file %s exceeds size limit by more than one line (%d bytes)", file, size)
+		}
+		total += lines
+	}
+	if total != nPoints {
+		t.Fatalf("unexpected number of data points, want %d, have %d", nPoints, total)
+	}
+}
+
+// readGzipLines reads a gzip compressed NDJSON file, returning its uncompressed
+// size, the number of lines and the length of the longest line
+func readGzipLines(t *testing.T, path string) (size, lines, maxLine int) {
+	t.Helper()
+
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		t.Fatalf("error opening %s: %s", path, err)
+	}
+	defer f.Close() // #nosec G104
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("error reading %s: %s", path, err)
+	}
+
+	scanner := bufio.NewScanner(gz)
+	for scanner.Scan() {
+		var p DataPoint
+		if err := json.Unmarshal(scanner.Bytes(), &p); err != nil {
+			t.Fatalf("invalid line in %s: %s", path, err)
+		}
+		n := len(scanner.Bytes()) + 1
+		size += n
+		if n > maxLine {
+			maxLine = n
+		}
+		lines++
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("error reading %s: %s", path, err)
+	}
+
+	return
+}
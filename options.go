@@ -0,0 +1,257 @@
+package sds011
+
+import "time"
+
+// DefaultTimeout denotes the default timeout for reading a reply / data from the device
+const DefaultTimeout = 5 * time.Second
+
+// Option denotes a functional option for an SDS011 sensor
+type Option func(*SDS011)
+
+// WithTimeout sets the default timeout for reading a reply / data from the device
+func WithTimeout(timeout time.Duration) Option {
+	return func(s *SDS011) {
+		s.timeout = timeout
+	}
+}
+
+// WithDiscardHandler sets a function that is called for each data point that is
+// discarded by WaitForDataFrom() because it originates from a different device
+func WithDiscardHandler(fn func(DataPoint)) Option {
+	return func(s *SDS011) {
+		s.onDiscard = fn
+	}
+}
+
+// WithTrace sets a function that is called for each raw frame sent to / received
+// from the device (e.g. for debugging or recording purposes)
+func WithTrace(fn TraceFunc) Option {
+	return func(s *SDS011) {
+		s.trace = fn
+	}
+}
+
+// WithModeCache enables caching of the last known work / reporting mode: Get*()
+// calls return the cached mode (if known) and Set*() calls are a no-op if the
+// device is known to already be in the requested mode, reducing serial traffic
+// NOTE: The cache cannot detect external changes (e.g. a power cycle of the
+// device), use RefreshWorkMode() / RefreshReportingMode() to force a query
+func WithModeCache() Option {
+	return func(s *SDS011) {
+		s.useModeCache = true
+	}
+}
+
+// WithLabels sets labels (e.g. location or host) attached to all data points
+// read from the device
+// NOTE: The labels are shared between all data points and must not be modified
+func WithLabels(labels map[string]string) Option {
+	return func(s *SDS011) {
+		s.labels = copyLabels(labels)
+	}
+}
+
+// NameLabel denotes the label carrying the name of the sensor (see WithName())
+const NameLabel = "name"
+
+// WithName sets a human-readable name of the sensor (e.g. "living-room"), which
+// is returned by Name() and attached to all data points as label (see NameLabel,
+// taking precedence over a label of the same name set via WithLabels()). By
+// default, the path / address of the device serves as name (without label).
+func WithName(name string) Option {
+	return func(s *SDS011) {
+		s.name = name
+	}
+}
+
+// WithBaudRate sets the baud rate of the serial port (default: 9600), with 0
+// selecting automatic detection (see DetectBaudRate())
+// NOTE: Only applies to serial ports opened via New()
+func WithBaudRate(rate int) Option {
+	return func(s *SDS011) {
+		s.baudRate = rate
+	}
+}
+
+// WithLineSettingsProbe enables a best-effort probe of the serial line settings
+// when opening the port, since some adapters require e.g. two stop bits or even
+// parity: If firmware queries using the default settings (8N1) repeatedly yield
+// corrupt frames, the AlternativeLineSettings are attempted and the first one
+// yielding a valid reply is adopted (see SDS011.LineSettings())
+// NOTE: Only applies to serial ports opened via New()
+func WithLineSettingsProbe() Option {
+	return func(s *SDS011) {
+		s.probeLine = true
+	}
+}
+
+// WithTCPKeepAlive sets the interval between TCP keepalive probes (0 selects the
+// default of 15 seconds, a negative value disables keepalive) and the number
+// of unanswered probes after which the connection is considered dead (0 selects
+// the system default, only supported on Linux), allowing silently dropped network
+// bridges to be detected quickly
+// NOTE: Only applies to network connections opened via NewTCP()
+func WithTCPKeepAlive(interval time.Duration, count int) Option {
+	return func(s *SDS011) {
+		s.keepAliveInterval = interval
+		s.keepAliveCount = count
+	}
+}
+
+// WithMinimumReadSize sets the minimum number of bytes a single read from the
+// serial port waits for (default: 1) and the time after which a read returns
+// early if no further bytes arrive (in steps of 100ms, at least 100ms if size is 0,
+// otherwise New() fails)
+// Larger values reduce the number of reads (and thus system calls / wakeups) at
+// the expense of latency, since data is only processed once size bytes (e.g. a
+// full frame of 10 bytes) are available. Frames are always assembled correctly,
+// regardless of the setting.
+// NOTE: Only applies to serial ports opened via New()
+func WithMinimumReadSize(size uint, readTimeout time.Duration) Option {
+	return func(s *SDS011) {
+		s.minReadSize = size
+		s.readTimeout = readTimeout
+	}
+}
+
+// WithInvalidAsNaN reports concentrations beyond the measurement range of the
+// device (see MaxConcentration) as NaN instead of their raw value, preventing
+// implausible values from e.g. a corrupt frame from skewing aggregates: All
+// analytics helpers (Bucketer, Smoother, Trend) skip NaN values
+// NOTE: NaN values cannot be encoded as JSON, data points have to be checked
+// (or filtered) before serialization
+func WithInvalidAsNaN() Option {
+	return func(s *SDS011) {
+		s.invalidAsNaN = true
+	}
+}
+
+// WithDataPointPool enables reuse of data points returned by QueryData*() and
+// WaitForData*() via a pool, reducing allocations (e.g. in continuous mode on
+// embedded devices). Ownership of a returned data point passes to the caller,
+// who may return it to the pool via Release() once it is no longer needed (data
+// points that are not released are simply garbage collected). Stream() releases
+// its data points automatically since they are emitted by value.
+func WithDataPointPool() Option {
+	return func(s *SDS011) {
+		s.usePool = true
+	}
+}
+
+// WithResync enables re-synchronization on corrupt data frames (e.g. caused by
+// line noise or misalignment in active reporting mode): Instead of returning an
+// error, WaitForData*() discards one byte at a time and retries framing until a
+// valid data frame is found (bounded, see Metrics.Resyncs). By default, the first
+// corrupt frame is reported as error.
+func WithResync() Option {
+	return func(s *SDS011) {
+		s.resync = true
+	}
+}
+
+// WithMinCommandInterval ensures that at least the provided interval elapses
+// between consecutive commands sent to the device (by default commands are sent
+// without delay), since some clones are known to lock up if commands arrive in
+// quick succession (e.g. when calling GetDiagnostics())
+func WithMinCommandInterval(interval time.Duration) Option {
+	return func(s *SDS011) {
+		s.minCommandInterval = interval
+	}
+}
+
+// WithCalibration applies a calibration to all data points read from the device
+// (see StreamRaw() to obtain both the raw and the calibrated values)
+func WithCalibration(c Calibration) Option {
+	return func(s *SDS011) {
+		s.calibration = &c
+	}
+}
+
+// WithScaleFactors sets independent factors converting the raw counts reported by
+// the device to PM2.5 / PM10 concentrations (default: 0.1 each, see
+// DefaultScaleFactors), e.g. to harmonize several sensors. The raw counts remain
+// accessible via DecodeCounts().
+// NOTE: The factors are applied before any calibration (see WithCalibration())
+func WithScaleFactors(factors ScaleFactors) Option {
+	return func(s *SDS011) {
+		s.scaleFactors = factors
+	}
+}
+
+// WithWakeZeroPolicy sets how the first data frame after waking the device (via
+// SetWorkMode()) is handled if it reports zero for both PM2.5 and PM10 (a known
+// quirk of the SDS011, default: WakeZeroKeep). The frame is either flagged (see
+// DataPoint.WarmingUp) or discarded, in which case the next frame is read instead.
+// NOTE: This is a heuristic: A genuine zero reading right after waking is treated
+// the same way, and wake-ups by the device itself (see SetWorkPeriod()) are not
+// detected
+func WithWakeZeroPolicy(policy WakeZeroPolicy) Option {
+	return func(s *SDS011) {
+		s.wakeZeroPolicy = policy
+	}
+}
+
+// WithSampleTimeEstimation enables the estimation of the true sample time of data
+// points emitted by Stream() / StreamRaw() (see DataPoint.EstimatedSampleTime),
+// compensating for the jitter of the reception time (e.g. due to buffering) by
+// aligning the reception times to the cadence of the device (once per second in
+// continuous operation, once per working period otherwise). The reception time
+// remains available as DataPoint.TimeStamp.
+func WithSampleTimeEstimation() Option {
+	return func(s *SDS011) {
+		s.estimateSampleTime = true
+	}
+}
+
+// WithRawCounts populates the raw (unscaled) counts reported by the device in all
+// data points (see DataPoint.CountPM25 / DataPoint.CountPM10), preserving full
+// fidelity e.g. for custom calibrations
+// NOTE: Zero counts are omitted from the JSON representation of a data point
+func WithRawCounts() Option {
+	return func(s *SDS011) {
+		s.rawCounts = true
+	}
+}
+
+// WithClock sets the source of the time stamps of all data points read from the
+// device (default: time.Now), e.g. a fixed clock for deterministic tests
+// NOTE: Timeouts are not affected and always based on the system clock
+func WithClock(now func() time.Time) Option {
+	return func(s *SDS011) {
+		s.now = now
+	}
+}
+
+// WithActiveModePolicy sets how QueryData*() behaves if the device is known to be
+// in active reporting mode (default: ActiveModePassthrough), i.e. whether an error
+// is returned, the device is temporarily switched to query reporting mode or the
+// next data frame reported by the device is returned
+func WithActiveModePolicy(policy ActiveModePolicy) Option {
+	return func(s *SDS011) {
+		s.activeModePolicy = policy
+	}
+}
+
+// WithFraming sets the header / tail bytes delimiting packets (default: 0xaa /
+// 0xab), e.g. for near-compatible devices or bridges altering the framing
+func WithFraming(header, tail byte) Option {
+	return func(s *SDS011) {
+		s.framing = framing{
+			header: header,
+			tail:   tail,
+		}
+	}
+}
+
+// WithAutoSleep puts the device to sleep after each successful QueryData*() call,
+// extending the lifetime of the laser diode / fan when polling. A subsequent call
+// wakes the device and waits for the provided settle time (e.g. MinSpinUp) for the
+// air flow to stabilize before querying data.
+// NOTE: Each call incurs the latency of the settle time plus two additional
+// commands (wake / sleep), unless the device is already known to be awake
+func WithAutoSleep(settle time.Duration) Option {
+	return func(s *SDS011) {
+		s.autoSleep = true
+		s.autoSleepSettle = settle
+	}
+}
@@ -0,0 +1,200 @@
+package sds011
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	packetHeader = 0xaa
+	packetTail   = 0xab
+
+	// dataFrameLen / replyFrameLen denote the length of a data / reply packet
+	dataFrameLen  = 10
+	replyFrameLen = 10
+)
+
+// frameLengths denotes the expected length per kind of packet (see ExpectedReplyLength())
+var frameLengths = map[PacketKind]int{
+	PacketKindData:  dataFrameLen,
+	PacketKindReply: replyFrameLen,
+}
+
+// frameLength returns the expected length of a packet of the provided kind
+// (unknown kinds are expected to match the length of a data packet)
+func frameLength(kind PacketKind) int {
+	if n, known := frameLengths[kind]; known {
+		return n
+	}
+	return dataFrameLen
+}
+
+// framing denotes the header / tail bytes delimiting packets
+type framing struct {
+	header byte
+	tail   byte
+}
+
+// defaultFraming denotes the framing used by the SDS011 protocol
+var defaultFraming = framing{
+	header: packetHeader,
+	tail:   packetTail,
+}
+
+// ErrUnexpectedPacket denotes that a packet of an unexpected kind (or in reply to
+// a different command) was received
+var ErrUnexpectedPacket = errors.New("unexpected packet")
+
+// PacketKind denotes the kind of a packet received from the device
+type PacketKind byte
+
+const (
+
+	// PacketKindData denotes a data packet (sent in active reporting mode or in
+	// reply to a data query)
+	PacketKindData = PacketKind(0xc0)
+
+	// PacketKindReply denotes a reply to a configuration command (echoing the
+	// sub-command)
+	PacketKindReply = PacketKind(0xc5)
+)
+
+// String returns the human-readable name of the packet kind, fulfilling the Stringer interface
+func (k PacketKind) String() string {
+	switch k {
+	case PacketKindData:
+		return "data"
+	case PacketKindReply:
+		return "reply"
+	}
+
+	return fmt.Sprintf("unknown (%02x)", byte(k))
+}
+
+// replyKind determines the kind of packet a command is answered with (data
+// queries are answered with a data packet, all other commands with a reply packet)
+func replyKind(cmd Command) PacketKind {
+	if cmd == CommandQueryData {
+		return PacketKindData
+	}
+	return PacketKindReply
+}
+
+// ExpectedReplyLength returns the length of the packet the device answers the
+// provided command with (see frameLengths)
+func ExpectedReplyLength(cmd Command) int {
+	return frameLengths[replyKind(cmd)]
+}
+
+// Packet denotes a packet received from the device
+type Packet struct {
+	Kind     PacketKind
+	Command  Command  // Echoed sub-command (reply packets only)
+	Payload  []byte   // Data bytes (bytes 2-5 of the packet)
+	DeviceID DeviceID // ID of the sending device
+}
+
+// ParsePacket parses and validates a raw packet received from the device
+func ParsePacket(frame []byte) (*Packet, error) {
+	return parsePacket(frame, defaultFraming)
+}
+
+func parsePacket(frame []byte, fr framing) (*Packet, error) {
+
+	if err := validateRxData(frame); err != nil {
+		return nil, err
+	}
+	if frame[0] != fr.header || frame[len(frame)-1] != fr.tail {
+		return nil, fmt.Errorf("%w: want %02x ... %02x, have %02x ... %02x", ErrInvalidFrame, fr.header, fr.tail, frame[0], frame[len(frame)-1])
+	}
+
+	p := &Packet{
+		Kind:     PacketKind(frame[1]),
+		Payload:  frame[2:6],
+		DeviceID: decodeDeviceID(frame),
+	}
+	switch p.Kind {
+	case PacketKindData:
+	case PacketKindReply:
+		p.Command = Command(frame[2])
+	default:
+		return nil, fmt.Errorf("%w: unknown packet kind %s", ErrUnexpectedPacket, p.Kind)
+	}
+
+	return p, nil
+}
+
+// ParseDataFrame decodes a raw data frame received from the device (e.g. from a
+// capture) into a data point, without any side effects: The time stamp is left
+// empty and the default scaling is applied (without any calibration). It never
+// panics and returns an error for any input other than a valid data frame, making
+// it suitable as entrypoint for fuzzing the decoder (e.g. via a go test fuzz target).
+func ParseDataFrame(frame []byte) (DataPoint, error) {
+
+	p, err := expectPacket(frame, defaultFraming, PacketKindData, 0)
+	if err != nil {
+		return DataPoint{}, err
+	}
+	pm25, pm10, err := decodeSensorValues(p.Payload, DefaultScaleFactors, false)
+	if err != nil {
+		return DataPoint{}, err
+	}
+
+	return DataPoint{
+		PM25:     pm25,
+		PM10:     pm10,
+		DeviceID: p.DeviceID,
+	}, nil
+}
+
+// expectPacket parses a raw packet and ensures that it is of the expected kind
+// (and in reply to the expected sub-command for reply packets)
+func expectPacket(frame []byte, fr framing, kind PacketKind, command Command) (*Packet, error) {
+	p, err := parsePacket(frame, fr)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Kind != kind {
+		return nil, fmt.Errorf("%w: want %s packet, have %s packet", ErrUnexpectedPacket, kind, p.Kind)
+	}
+	if kind == PacketKindReply && p.Command != command {
+		return nil, fmt.Errorf("%w: want reply to command %s, have reply to command %s", ErrUnexpectedPacket, command, p.Command)
+	}
+
+	return p, nil
+}
+
+// AnalyzeChecksum is a diagnostic helper determining if the checksum of a frame
+// is valid (ok) and, if not, the index of the first byte in the frame (checksum
+// included) in which flipping a single bit would yield a valid checksum
+// (suspectIndex is -1 if the checksum is valid or no single-bit error can
+// explain the mismatch). A single-bit error usually indicates a noisy link,
+// whereas larger deviations point towards framing / protocol issues.
+// NOTE: Several bytes may be suspects, only the first one is reported
+func AnalyzeChecksum(frame []byte) (ok bool, suspectIndex int) {
+	if len(frame) != dataFrameLen {
+		return false, -1
+	}
+
+	sum := calcChecksum(frame[2:8])
+	if sum == frame[8] {
+		return true, -1
+	}
+
+	// Check if flipping a single bit of a payload byte changes the sum as required
+	for i := 2; i < 8; i++ {
+		for bit := 0; bit < 8; bit++ {
+			if sum-frame[i]+(frame[i]^(1<<bit)) == frame[8] {
+				return false, i
+			}
+		}
+	}
+
+	// Check if the checksum itself differs by a single bit
+	if diff := sum ^ frame[8]; diff&(diff-1) == 0 {
+		return false, 8
+	}
+
+	return false, -1
+}
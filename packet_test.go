@@ -0,0 +1,45 @@
+package sds011
+
+import (
+	"bytes"
+	"testing"
+)
+
+func FuzzParseDataFrame(f *testing.F) {
+
+	valid := mockDataFrame(9999, 0xffff)
+	f.Add(valid)
+	f.Add(valid[:9])
+	f.Add(append(append([]byte{}, valid...), packetTail))
+	f.Add(mockReply(CommandFirmware, 18, 11, 16))
+	f.Add([]byte{})
+	f.Add([]byte{packetHeader, byte(PacketKindData)})
+
+	f.Fuzz(func(t *testing.T, frame []byte) {
+		dataPoint, err := ParseDataFrame(frame)
+		if err != nil {
+			return
+		}
+
+		// Only valid data frames may be accepted
+		if len(frame) != dataFrameLen || frame[0] != packetHeader || frame[len(frame)-1] != packetTail ||
+			PacketKind(frame[1]) != PacketKindData || calcChecksum(frame[2:8]) != frame[8] {
+			t.Fatalf("invalid frame %x accepted", frame)
+		}
+
+		// Re-encoding the decoded counts must yield the original frame
+		count25, count10, err := DecodeCounts(frame[2:6])
+		if err != nil {
+			t.Fatalf("error decoding counts of accepted frame %x: %s", frame, err)
+		}
+		reencoded := mockDataFrame(count25, count10)
+		reencoded[6], reencoded[7] = frame[6], frame[7]
+		reencoded[8] = calcChecksum(reencoded[2:8])
+		if !bytes.Equal(reencoded, frame) {
+			t.Fatalf("frame %x does not round-trip, have %x", frame, reencoded)
+		}
+		if dataPoint.DeviceID != decodeDeviceID(frame) {
+			t.Fatalf("unexpected device ID, want %s, have %s", decodeDeviceID(frame), dataPoint.DeviceID)
+		}
+	})
+}
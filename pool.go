@@ -0,0 +1,40 @@
+package sds011
+
+import "sync"
+
+var dataPointPool = sync.Pool{
+	New: func() interface{} {
+		return new(DataPoint)
+	},
+}
+
+// Release returns a data point obtained from a sensor with pooling enabled (see
+// WithDataPointPool()) to the pool for reuse. The data point (and any copy of
+// the pointer) must not be accessed after calling Release. Calling Release on a
+// data point that was not obtained from the pool is a no-op.
+func (p *DataPoint) Release() {
+	if p == nil || !p.pooled {
+		return
+	}
+
+	*p = DataPoint{}
+	dataPointPool.Put(p)
+}
+
+// newPooledDataPoint obtains a data point from the pool
+func newPooledDataPoint() *DataPoint {
+	p := dataPointPool.Get().(*DataPoint)
+	p.pooled = true
+
+	return p
+}
+
+// take returns a copy of the data point and releases it to the pool (if it was
+// obtained from the pool)
+func (p *DataPoint) take() DataPoint {
+	res := *p
+	res.pooled = false
+	p.Release()
+
+	return res
+}
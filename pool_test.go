@@ -0,0 +1,49 @@
+package sds011
+
+import "testing"
+
+func BenchmarkNewDataPoint(b *testing.B) {
+
+	frame := mockDataFrame(123, 456)
+	for _, bm := range []struct {
+		name string
+		opts []Option
+	}{
+		{"unpooled", nil},
+		{"pooled", []Option{WithDataPointPool()}},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			s := newSDS011("bench", bm.opts...)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				dataPoint, err := s.newDataPoint(frame)
+				if err != nil {
+					b.Fatal(err)
+				}
+				dataPoint.Release()
+			}
+		})
+	}
+}
+
+func TestDataPointPoolAllocations(t *testing.T) {
+
+	frame := mockDataFrame(123, 456)
+	allocs := func(opts ...Option) float64 {
+		s := newSDS011("test", opts...)
+		return testing.AllocsPerRun(100, func() {
+			dataPoint, err := s.newDataPoint(frame)
+			if err != nil {
+				t.Fatal(err)
+			}
+			dataPoint.Release()
+		})
+	}
+
+	unpooled, pooled := allocs(), allocs(WithDataPointPool())
+	if pooled >= unpooled {
+		t.Fatalf("pooling does not reduce allocations, have %v (pooled) vs. %v (unpooled)", pooled, unpooled)
+	}
+}
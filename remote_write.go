@@ -0,0 +1,261 @@
+package sds011
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+)
+
+const (
+
+	// RemoteWriteMetricPM25 denotes the metric name of PM2.5 concentrations
+	// pushed by a RemoteWriteSink
+	RemoteWriteMetricPM25 = "sds011_pm25_micrograms_per_cubic_meter"
+
+	// RemoteWriteMetricPM10 denotes the metric name of PM10 concentrations
+	// pushed by a RemoteWriteSink
+	RemoteWriteMetricPM10 = "sds011_pm10_micrograms_per_cubic_meter"
+)
+
+// RemoteWriteSink denotes a BatchSink that pushes data points to a Prometheus
+// remote-write endpoint (protobuf, snappy-compressed). Each data point yields
+// one sample for each of the PM2.5 / PM10 metrics, labelled with the labels of
+// the data point (and its device ID, if set).
+// NOTE: Batching can be achieved by wrapping the sink in a BufferedWriter
+type RemoteWriteSink struct {
+	url          string
+	client       *http.Client
+	retries      int
+	retryBackoff time.Duration
+}
+
+// RemoteWriteOption denotes a functional option for a RemoteWriteSink
+type RemoteWriteOption func(*RemoteWriteSink)
+
+// WithRemoteWriteTimeout sets the timeout for each individual HTTP request
+func WithRemoteWriteTimeout(timeout time.Duration) RemoteWriteOption {
+	return func(s *RemoteWriteSink) {
+		s.client.Timeout = timeout
+	}
+}
+
+// WithRemoteWriteRetries sets the number of retries (and the delay between them)
+// in case a request fails (client errors other than 429 are never retried)
+func WithRemoteWriteRetries(retries int, backoff time.Duration) RemoteWriteOption {
+	return func(s *RemoteWriteSink) {
+		s.retries = retries
+		s.retryBackoff = backoff
+	}
+}
+
+// WithRemoteWriteClient sets a custom HTTP client to perform the requests (e.g.
+// to provide authentication)
+func WithRemoteWriteClient(client *http.Client) RemoteWriteOption {
+	return func(s *RemoteWriteSink) {
+		s.client = client
+	}
+}
+
+// NewRemoteWriteSink creates a new RemoteWriteSink pushing to the provided URL
+func NewRemoteWriteSink(url string, opts ...RemoteWriteOption) *RemoteWriteSink {
+	s := &RemoteWriteSink{
+		url: url,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		retryBackoff: time.Second,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Write pushes a single data point to the endpoint
+func (s *RemoteWriteSink) Write(p DataPoint) error {
+	return s.WriteBatch([]DataPoint{p})
+}
+
+// WriteBatch pushes several data points to the endpoint in a single request,
+// retrying if requested
+func (s *RemoteWriteSink) WriteBatch(points []DataPoint) error {
+	if len(points) == 0 {
+		return nil
+	}
+
+	body := encodeSnappy(encodeWriteRequest(points))
+
+	var err error
+	for i := 0; ; i++ {
+		var retryable bool
+		if retryable, err = s.post(body); err == nil || !retryable || i >= s.retries {
+			break
+		}
+		time.Sleep(s.retryBackoff)
+	}
+
+	return err
+}
+
+// Close fulfills the Sink interface (there are no resources to release)
+func (s *RemoteWriteSink) Close() error {
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func (s *RemoteWriteSink) post(body []byte) (bool, error) {
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("error creating remote-write request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("error pushing to remote-write endpoint %s: %w", s.url, err)
+	}
+	defer resp.Body.Close()
+
+	// Drain the body to allow for connection reuse
+	io.Copy(io.Discard, resp.Body) // #nosec G104
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		retryable := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return retryable, fmt.Errorf("unexpected remote-write response status: %s", resp.Status)
+	}
+
+	return false, nil
+}
+
+// remoteWriteLabel denotes a single label of a time series
+type remoteWriteLabel struct {
+	name, value string
+}
+
+// remoteWriteSeries denotes a time series and its samples
+type remoteWriteSeries struct {
+	labels     []remoteWriteLabel
+	values     []float64
+	timeStamps []int64
+}
+
+// encodeWriteRequest encodes a set of data points as (uncompressed) protobuf
+// WriteRequest message, grouping samples by time series
+func encodeWriteRequest(points []DataPoint) []byte {
+
+	var series []*remoteWriteSeries
+	index := make(map[string]*remoteWriteSeries)
+
+	add := func(name string, p DataPoint, value float64) {
+		labels := []remoteWriteLabel{{"__name__", name}}
+		if p.DeviceID != 0 {
+			labels = append(labels, remoteWriteLabel{"device_id", p.DeviceID.String()})
+		}
+		for k, v := range p.Labels {
+			labels = append(labels, remoteWriteLabel{k, v})
+		}
+		sort.Slice(labels, func(i, j int) bool {
+			return labels[i].name < labels[j].name
+		})
+
+		var key bytes.Buffer
+		for _, label := range labels {
+			key.WriteString(label.name + "\x00" + label.value + "\x00")
+		}
+
+		ts, exists := index[key.String()]
+		if !exists {
+			ts = &remoteWriteSeries{labels: labels}
+			index[key.String()] = ts
+			series = append(series, ts)
+		}
+		ts.values = append(ts.values, value)
+		ts.timeStamps = append(ts.timeStamps, p.TimeStamp.UnixNano()/int64(time.Millisecond))
+	}
+	for _, p := range points {
+		add(RemoteWriteMetricPM25, p, p.PM25)
+		add(RemoteWriteMetricPM10, p, p.PM10)
+	}
+
+	// WriteRequest: repeated TimeSeries timeseries = 1
+	var req []byte
+	for _, ts := range series {
+
+		// TimeSeries: repeated Label labels = 1, repeated Sample samples = 2
+		var msg []byte
+		for _, label := range ts.labels {
+			var l []byte
+			l = appendProtoBytes(l, 1, []byte(label.name))
+			l = appendProtoBytes(l, 2, []byte(label.value))
+			msg = appendProtoBytes(msg, 1, l)
+		}
+		for i := range ts.values {
+
+			// Sample: double value = 1, int64 timestamp = 2
+			sample := appendProtoKey(nil, 1, 1)
+			sample = append(sample, make([]byte, 8)...)
+			binary.LittleEndian.PutUint64(sample[len(sample)-8:], math.Float64bits(ts.values[i]))
+			sample = appendProtoKey(sample, 2, 0)
+			sample = appendUvarint(sample, uint64(ts.timeStamps[i]))
+			msg = appendProtoBytes(msg, 2, sample)
+		}
+
+		req = appendProtoBytes(req, 1, msg)
+	}
+
+	return req
+}
+
+// appendProtoKey appends a protobuf field key (field number and wire type)
+func appendProtoKey(buf []byte, field, wireType int) []byte {
+	return appendUvarint(buf, uint64(field<<3|wireType))
+}
+
+// appendUvarint appends a varint-encoded unsigned integer
+func appendUvarint(buf []byte, val uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	return append(buf, tmp[:binary.PutUvarint(tmp[:], val)]...)
+}
+
+// appendProtoBytes appends a length-delimited protobuf field
+func appendProtoBytes(buf []byte, field int, data []byte) []byte {
+	buf = appendProtoKey(buf, field, 2)
+	buf = appendUvarint(buf, uint64(len(data)))
+	return append(buf, data...)
+}
+
+// encodeSnappy encodes data in the snappy block format (as required by the
+// remote-write protocol), using literals only
+// NOTE: The payloads are small, hence the lack of actual compression is a fair
+// trade-off for not requiring an additional dependency
+func encodeSnappy(data []byte) []byte {
+
+	const maxLiteral = 1 << 16
+
+	buf := appendUvarint(make([]byte, 0, len(data)+len(data)/maxLiteral*3+8), uint64(len(data)))
+	for len(data) > 0 {
+		n := len(data)
+		if n > maxLiteral {
+			n = maxLiteral
+		}
+
+		// Literal tag with a two byte length (minus one) following
+		buf = append(buf, 61<<2, byte(n-1), byte((n-1)>>8))
+		buf = append(buf, data[:n]...)
+		data = data[n:]
+	}
+
+	return buf
+}
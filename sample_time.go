@@ -0,0 +1,78 @@
+package sds011
+
+import "time"
+
+const (
+
+	// sampleTimePhaseGain denotes the fraction of the phase error (deviation of
+	// the reception time from the predicted sample time) corrected per frame
+	sampleTimePhaseGain = 0.1
+
+	// sampleTimePeriodGain denotes the fraction of the phase error used to correct
+	// the estimated period (compensating for drift of the device clock)
+	sampleTimePeriodGain = 0.01
+
+	// continuousPeriod denotes the nominal interval between frames in continuous
+	// operation
+	continuousPeriod = time.Second
+)
+
+// sampleTimeEstimator estimates the true sample time of frames received in active
+// reporting mode by aligning the reception times to the (nominal) cadence of the
+// device, using a simple phase-locked loop: Each reception time is compared to
+// the predicted sample time and only a fraction of the deviation (typically caused
+// by buffering / scheduling delays) is applied to the phase and period estimates
+type sampleTimeEstimator struct {
+	period time.Duration
+	last   time.Time
+}
+
+// newSampleTimeEstimator creates a new estimator based on the working period of
+// the device (if known), or nil if the estimation is disabled
+func (s *SDS011) newSampleTimeEstimator() *sampleTimeEstimator {
+	if !s.estimateSampleTime {
+		return nil
+	}
+
+	period := continuousPeriod
+	if delayMinutes, ok := s.cachedWorkPeriod(); ok && delayMinutes != WorkPeriodContinuous {
+		period = time.Duration(delayMinutes) * time.Minute
+	}
+
+	return &sampleTimeEstimator{
+		period: period,
+	}
+}
+
+// annotate sets the estimated sample time of a data point based on its reception
+// time (no-op if the estimator is nil)
+func (e *sampleTimeEstimator) annotate(p *DataPoint) {
+	if e == nil {
+		return
+	}
+
+	est := e.update(p.TimeStamp)
+	p.EstimatedSampleTime = &est
+}
+
+// update processes the reception time of a frame and returns its estimated sample time
+func (e *sampleTimeEstimator) update(received time.Time) time.Time {
+
+	// The first frame (or one received less than half a period after the previous
+	// one, e.g. after a clock jump) (re-)initializes the phase
+	elapsed := received.Sub(e.last)
+	if e.last.IsZero() || elapsed < e.period/2 {
+		e.last = received
+		return received
+	}
+
+	// Account for missed frames
+	frames := (elapsed + e.period/2) / e.period
+	predicted := e.last.Add(frames * e.period)
+	phaseErr := received.Sub(predicted)
+
+	e.last = predicted.Add(time.Duration(sampleTimePhaseGain * float64(phaseErr)))
+	e.period += time.Duration(sampleTimePeriodGain * float64(phaseErr) / float64(frames))
+
+	return e.last
+}
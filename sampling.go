@@ -0,0 +1,107 @@
+package sds011
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// QueryMedian queries n data points from the sensor (waiting interval between
+// consecutive queries) and returns the per-field median, which is more robust
+// against occasional spikes than the mean (carrying the time stamp and metadata
+// of the last data point)
+// NOTE: Requires continuous operation, ErrPeriodicWorkMode is returned if a
+// working period is configured (see SetWorkPeriod())
+func (s *SDS011) QueryMedian(ctx context.Context, n int, interval time.Duration) (*DataPoint, error) {
+	points, err := s.querySamples(ctx, n, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	res := points[len(points)-1]
+	res.PM25 = median(points, func(p DataPoint) float64 { return p.PM25 })
+	res.PM10 = median(points, func(p DataPoint) float64 { return p.PM10 })
+
+	return &res, nil
+}
+
+// QueryAverage queries n data points from the sensor (waiting interval between
+// consecutive queries) and returns the per-field mean (carrying the time stamp
+// and metadata of the last data point)
+// NOTE: Requires continuous operation, ErrPeriodicWorkMode is returned if a
+// working period is configured (see SetWorkPeriod())
+func (s *SDS011) QueryAverage(ctx context.Context, n int, interval time.Duration) (*DataPoint, error) {
+	points, err := s.querySamples(ctx, n, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	res := points[len(points)-1]
+	res.PM25, res.PM10 = mean(points)
+
+	return &res, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// querySamples queries n data points from the sensor, waiting interval between
+// consecutive queries
+// NOTE: In periodic operation the device only measures once per working period
+// and would not provide independent samples at the requested interval, hence
+// the working period is determined first (if unknown) and has to be continuous
+func (s *SDS011) querySamples(ctx context.Context, n int, interval time.Duration) ([]DataPoint, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("invalid number of samples %d, must be at least 1", n)
+	}
+
+	delayMinutes, ok := s.cachedWorkPeriod()
+	if !ok {
+		var err error
+		if delayMinutes, err = s.GetWorkPeriod(); err != nil {
+			return nil, fmt.Errorf("error determining work period: %w", err)
+		}
+	}
+	if delayMinutes != WorkPeriodContinuous {
+		return nil, fmt.Errorf("%w (every %d minute(s)), cannot query samples at an interval of %v", ErrPeriodicWorkMode, delayMinutes, interval)
+	}
+
+	points := make([]DataPoint, 0, n)
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			if err := sleepContext(ctx, interval); err != nil {
+				return nil, err
+			}
+		}
+
+		dataPoint, err := s.QueryDataContext(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error reading sample %d of %d: %w", i+1, n, err)
+		}
+		points = append(points, dataPoint.take())
+	}
+
+	return points, nil
+}
+
+// median computes the median of a single field of a set of data points, skipping
+// NaN values (yielding NaN if no valid value exists)
+func median(points []DataPoint, field func(DataPoint) float64) float64 {
+	values := make([]float64, 0, len(points))
+	for _, p := range points {
+		if val := field(p); !math.IsNaN(val) {
+			values = append(values, val)
+		}
+	}
+	if len(values) == 0 {
+		return math.NaN()
+	}
+
+	sort.Float64s(values)
+	if len(values)%2 == 1 {
+		return values[len(values)/2]
+	}
+
+	return (values[len(values)/2-1] + values[len(values)/2]) / 2
+}
@@ -0,0 +1,59 @@
+package sds011
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestMedianRejectsOutliers(t *testing.T) {
+	for _, cs := range []struct {
+		values   []float64
+		expected float64
+	}{
+		{[]float64{10}, 10},
+		{[]float64{10, 11, 999.9}, 11},
+		{[]float64{0, 10, 11, 12, 999.9}, 11},
+		{[]float64{10, 999.9, 12, 11}, 11.5},
+		{[]float64{10, math.NaN(), 12, 999.9, 11}, 11.5},
+		{[]float64{math.NaN()}, math.NaN()},
+	} {
+		points := make([]DataPoint, 0, len(cs.values))
+		for _, val := range cs.values {
+			points = append(points, DataPoint{PM25: val})
+		}
+
+		res := median(points, func(p DataPoint) float64 { return p.PM25 })
+		if res != cs.expected && !(math.IsNaN(res) && math.IsNaN(cs.expected)) {
+			t.Fatalf("unexpected median of %v, want %v, have %v", cs.values, cs.expected, res)
+		}
+	}
+}
+
+func TestQueryMedian(t *testing.T) {
+
+	// Report a spike on one field and a drop-out on the other
+	d := newMockDevice()
+	d.samples = [][2]uint16{{100, 200}, {9999, 210}, {110, 0}, {105, 205}, {95, 190}}
+	s := newMockSensor(t, d)
+
+	res, err := s.QueryMedian(context.Background(), len(d.samples), 0)
+	if err != nil {
+		t.Fatalf("error querying median: %s", err)
+	}
+	if math.Abs(res.PM25-10.5) > 1e-9 || math.Abs(res.PM10-20) > 1e-9 {
+		t.Fatalf("unexpected median, want 10.5 / 20, have %v / %v", res.PM25, res.PM10)
+	}
+}
+
+func TestQueryMedianPeriodicWorkMode(t *testing.T) {
+
+	d := newMockDevice()
+	d.workPeriod = 5
+	s := newMockSensor(t, d)
+
+	if _, err := s.QueryMedian(context.Background(), 3, 0); !errors.Is(err, ErrPeriodicWorkMode) {
+		t.Fatalf("unexpected error, want %v, have %v", ErrPeriodicWorkMode, err)
+	}
+}
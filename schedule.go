@@ -0,0 +1,39 @@
+package sds011
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+var (
+	jitterRNG   = rand.New(rand.NewSource(time.Now().UnixNano())) // #nosec G404
+	jitterMutex sync.Mutex
+)
+
+// NextInterval returns the base interval with a uniformly distributed random
+// jitter in [-jitter, +jitter] applied, allowing to spread out the measurements
+// of many sensors running on the same interval (the result is never negative)
+func NextInterval(base, jitter time.Duration) time.Duration {
+	if jitter <= 0 {
+		return base
+	}
+
+	jitterMutex.Lock()
+	offset := time.Duration(jitterRNG.Int63n(2*int64(jitter)+1)) - jitter
+	jitterMutex.Unlock()
+
+	if interval := base + offset; interval > 0 {
+		return interval
+	}
+
+	return 0
+}
+
+// SeedJitter seeds the random number generator used by NextInterval, rendering
+// the sequence of intervals deterministic (e.g. for tests)
+func SeedJitter(seed int64) {
+	jitterMutex.Lock()
+	jitterRNG.Seed(seed)
+	jitterMutex.Unlock()
+}
@@ -1,12 +1,14 @@
 package sds011
 
 import (
-	"bufio"
-	"bytes"
+	"context"
 	"encoding/binary"
-	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"math"
+	"os"
+	"sync"
 	"time"
 
 	"github.com/jacobsa/go-serial/serial"
@@ -19,6 +21,8 @@ type ReportingMode string
 type WorkMode string
 
 // Command prefixes
+//
+// Deprecated: Use BuildCommand() / the Command type instead
 const (
 	CommandGetFirmwarePrefix      = "aab40700"
 	CommandGetWorkModePrefix      = "aab40600"
@@ -28,8 +32,32 @@ const (
 	CommandSetWorkModePrefix      = "aab40601"
 	CommandSetReportingModePrefix = "aab40201"
 	CommandSetWorkPeriodPrefix    = "aab40801"
+)
+
+const (
+
+	// maxCorruptFrames denotes the maximum number of corrupt frames skipped when
+	// collecting several data points
+	maxCorruptFrames = 5
+
+	// minReadTimeout denotes the minimum read timeout if no minimum read size
+	// is set (see WithMinimumReadSize())
+	minReadTimeout = 100 * time.Millisecond
+
+	// maxResyncBytes denotes the maximum number of bytes discarded while trying
+	// to re-establish frame alignment (see WithResync())
+	maxResyncBytes = 2 * maxFrameSize
 
-	expectedDataLen = 10
+	// MaxConcentration denotes the upper limit of the measurement range of the
+	// device (in μg / ㎥)
+	MaxConcentration = 999.9
+
+	// maxCount denotes the raw count corresponding to MaxConcentration
+	maxCount = 9999
+
+	// TryQueryTimeout denotes the timeout for a reply from the device used by
+	// TryQueryData() (covering the transmission time of a frame plus a margin)
+	TryQueryTimeout = 100 * time.Millisecond
 )
 
 const (
@@ -55,72 +83,345 @@ const (
 
 // SDS011 denotes a Nova Fitness SDS011 fine dust sensor endpoint
 type SDS011 struct {
-	socket string
-	port   io.ReadWriteCloser
+	socket  string
+	name    string
+	timeout time.Duration
+
+	conn        *connection
+	open        func() (io.ReadWriteCloser, error)
+	isClosed    bool
+	portMutex   sync.Mutex
+	baudRate    int
+	probeLine   bool
+	minReadSize uint
+	readTimeout time.Duration
+
+	lineSettings LineSettings
+
+	keepAliveInterval time.Duration
+	keepAliveCount    int
+
+	labels       map[string]string
+	onDiscard    func(DataPoint)
+	trace        TraceFunc
+	metrics      metrics
+	invalidAsNaN bool
+	usePool      bool
+	resync       bool
+	calibration  *Calibration
+	framing      framing
+	scaleFactors ScaleFactors
+	rawCounts    bool
+	now          func() time.Time
+
+	activeModePolicy ActiveModePolicy
+
+	autoSleep       bool
+	autoSleepSettle time.Duration
+	wakeZeroPolicy  WakeZeroPolicy
+	justWoken       int32
+
+	estimateSampleTime bool
+	subscribers        subscribers
+
+	useModeCache bool
+	modeCache    modeCache
+
+	minCommandInterval time.Duration
+	lastCommand        time.Time
+	commandMutex       sync.Mutex
 }
 
 // New creates a new SDS011 object
-func New(socket string) (*SDS011, error) {
+func New(socket string, opts ...Option) (*SDS011, error) {
+
+	s := newSDS011(socket, opts...)
 
-	// Define default options for SDS011 device
-	defaultOptions := serial.OpenOptions{
-		PortName:        socket,
-		BaudRate:        9600,
-		DataBits:        8,
-		StopBits:        1,
-		ParityMode:      serial.PARITY_NONE,
-		MinimumReadSize: 1,
+	// Without a minimum read size, reads only return after the read timeout
+	// (otherwise the port would be polled continuously via empty reads)
+	if s.minReadSize == 0 && s.readTimeout < minReadTimeout {
+		return nil, fmt.Errorf("invalid read settings, a read timeout of at least %v is required if the minimum read size is 0, have %v", minReadTimeout, s.readTimeout)
 	}
 
-	// Open the port
-	port, err := serial.Open(defaultOptions)
+	// Open the port (using the current baud rate / line settings, which may change
+	// when detected / probed). If requested, the baud rate of the device is detected
+	// first (leaving the port open at the detected rate).
+	s.open = s.openSerial
+	if s.baudRate == 0 {
+		if err := s.detectBaudRate(); err != nil {
+			return nil, err
+		}
+	} else {
+		port, err := s.open()
+		if err != nil {
+			return nil, err
+		}
+		s.conn = s.newConnection(port, s.minReadSize == 0)
+	}
+
+	if s.probeLine {
+		if err := s.probeLineSettings(); err != nil {
+			s.Close() // #nosec G104
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// openSerial opens the serial port of the device using the current settings
+func (s *SDS011) openSerial() (io.ReadWriteCloser, error) {
+	port, err := serial.Open(serial.OpenOptions{
+		PortName:              s.socket,
+		BaudRate:              uint(s.baudRate),
+		DataBits:              8,
+		StopBits:              s.lineSettings.StopBits,
+		ParityMode:            s.lineSettings.Parity.serialMode(),
+		MinimumReadSize:       s.minReadSize,
+		InterCharacterTimeout: uint(s.readTimeout.Milliseconds()),
+	})
 	if err != nil {
-		return nil, err
+		return nil, wrapOpenError(s.socket, err)
+	}
+
+	return port, nil
+}
+
+// NewFromFile creates a new SDS011 object from an already open file descriptor
+// of the serial device (e.g. handed down from a supervisor process), avoiding
+// reopening the device
+// NOTE: On Linux the serial line settings (9600 8N1, raw mode) are configured on
+// the existing descriptor, on all other platforms the descriptor is used as-is
+// and must already be configured appropriately. Since the descriptor is owned by
+// the caller, the sensor cannot reconnect on its own.
+func NewFromFile(f *os.File, opts ...Option) (*SDS011, error) {
+
+	s := newSDS011(f.Name(), opts...)
+
+	if err := configureFile(f); err != nil {
+		return nil, fmt.Errorf("error configuring serial line settings on %s: %w", f.Name(), err)
+	}
+	s.conn = s.newConnection(f, false)
+	s.open = func() (io.ReadWriteCloser, error) {
+		return nil, fmt.Errorf("cannot reopen %s, sensor was created from an existing descriptor", f.Name())
+	}
+
+	return s, nil
+}
+
+// wrapOpenError adds context (and a hint for common causes) to an error
+// encountered while opening the port
+func wrapOpenError(socket string, err error) error {
+	switch {
+	case errors.Is(err, os.ErrPermission):
+		return fmt.Errorf("error opening %s (permission denied, is the user a member of the `dialout` group?): %w", socket, err)
+	case errors.Is(err, os.ErrNotExist):
+		return fmt.Errorf("error opening %s (device not present, is the sensor plugged in?): %w", socket, err)
+	}
+
+	return fmt.Errorf("error opening %s: %w", socket, err)
+}
+
+// newSDS011 creates a new (unconnected) object and applies all functional options
+func newSDS011(socket string, opts ...Option) *SDS011 {
+	s := &SDS011{
+		socket:       socket,
+		timeout:      DefaultTimeout,
+		baudRate:     DefaultBaudRate,
+		minReadSize:  1,
+		framing:      defaultFraming,
+		scaleFactors: DefaultScaleFactors,
+		lineSettings: DefaultLineSettings,
+		now:          time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.applyNameLabel()
+
+	return s
+}
+
+// applyNameLabel propagates an explicitly set name to all data points (see WithName())
+func (s *SDS011) applyNameLabel() {
+	if s.name != "" {
+		if s.labels = copyLabels(s.labels); s.labels == nil {
+			s.labels = make(map[string]string, 1)
+		}
+		s.labels[NameLabel] = s.name
+	}
+}
+
+// Name returns the human-readable name of the sensor (see WithName()), defaulting
+// to the path / address of the device
+func (s *SDS011) Name() string {
+	if s.name == "" {
+		return s.socket
 	}
 
-	// Create and return new object
-	return &SDS011{
-		socket: socket,
-		port:   port,
-	}, nil
+	return s.name
 }
 
 // Close closes the connection to the device
 func (s *SDS011) Close() error {
-	return s.port.Close()
+	s.portMutex.Lock()
+	defer s.portMutex.Unlock()
+
+	s.isClosed = true
+
+	return s.conn.close()
+}
+
+// Shutdown puts the device to sleep (to conserve lifetime of the laser) and closes
+// the connection to the device, the connection is closed even if the device
+// could not be put to sleep
+func (s *SDS011) Shutdown() error {
+	return shutdown(s)
 }
 
 // GetFirmware determines the firmware version of the sensor
 func (s *SDS011) GetFirmware() (string, error) {
-	rxData, err := s.executeCommand(CommandGetFirmwarePrefix + "0000000000000000000000ffff")
+	return s.GetFirmwareTimeout(s.timeout)
+}
+
+// GetFirmwareTimeout determines the firmware version of the sensor, using a custom timeout
+// NOTE: The device ID contained in the reply is retained (see DeviceID())
+func (s *SDS011) GetFirmwareTimeout(timeout time.Duration) (string, error) {
+	return s.getFirmware(context.Background(), timeout)
+}
+
+// GetFirmwareContext determines the firmware version of the sensor, aborting if
+// the context is cancelled
+func (s *SDS011) GetFirmwareContext(ctx context.Context) (string, error) {
+	return s.getFirmware(ctx, s.timeout)
+}
+
+func (s *SDS011) getFirmware(ctx context.Context, timeout time.Duration) (string, error) {
+	rxData, err := s.executeCommand(ctx, timeout, CommandFirmware)
 	if err != nil {
 		return "", err
 	}
+	s.setCachedDeviceID(decodeDeviceID(rxData))
 
-	return fmt.Sprintf("20%d-%d-%d",
-		int(rxData[3]),
-		int(rxData[4]),
-		int(rxData[5])), nil
+	return decodeFirmware(rxData).String(), nil
 }
 
-// GetWorkMode determines the current working mode of the sensor
-func (s *SDS011) GetWorkMode() (WorkMode, error) {
-	rxData, err := s.executeCommand(CommandGetWorkModePrefix + "0000000000000000000000ffff")
+// DeviceID returns the ID of the device as reported by the last firmware query
+// (via GetFirmware() or GetDiagnostics()), without querying the device. If no
+// such query has been performed (since the last reconnect), false is returned
+func (s *SDS011) DeviceID() (DeviceID, bool) {
+	return s.cachedDeviceID()
+}
+
+// SetDeviceID changes the ID of the device (as reported in all packets it sends)
+// NOTE: The current ID is determined first (via a firmware query) in order to
+// address the command to the device, hence only a single device may be attached
+func (s *SDS011) SetDeviceID(id DeviceID) error {
+	return s.SetDeviceIDTimeout(id, s.timeout)
+}
+
+// SetDeviceIDTimeout changes the ID of the device, using a custom timeout
+func (s *SDS011) SetDeviceIDTimeout(id DeviceID, timeout time.Duration) error {
+	return s.setDeviceID(context.Background(), id, timeout)
+}
+
+// SetDeviceIDContext changes the ID of the device, aborting if the context is cancelled
+func (s *SDS011) SetDeviceIDContext(ctx context.Context, id DeviceID) error {
+	return s.setDeviceID(ctx, id, s.timeout)
+}
+
+func (s *SDS011) setDeviceID(ctx context.Context, id DeviceID, timeout time.Duration) error {
+
+	if id == DeviceIDAll {
+		return fmt.Errorf("invalid device ID %s (reserved for broadcasts)", id)
+	}
+	if _, err := s.getFirmware(ctx, timeout); err != nil {
+		return fmt.Errorf("error determining current device ID: %w", err)
+	}
+	currentID, _ := s.cachedDeviceID()
+
+	// The new ID is carried in the last two data bytes
+	data := make([]byte, commandDataLen)
+	data[commandDataLen-2], data[commandDataLen-1] = byte(id>>8), byte(id)
+	rxData, err := s.executeCommandFor(ctx, timeout, currentID, CommandDeviceID, data...)
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	return WorkMode(hex.EncodeToString([]byte{rxData[4]})), nil
+	confirmedID := decodeDeviceID(rxData)
+	s.setCachedDeviceID(confirmedID)
+	if confirmedID != id {
+		return fmt.Errorf("unexpected device ID confirmation, want %s, have %s", id, confirmedID)
+	}
+
+	return nil
+}
+
+// GetWorkMode determines the current working mode of the sensor
+func (s *SDS011) GetWorkMode() (WorkMode, error) {
+	return s.GetWorkModeTimeout(s.timeout)
+}
+
+// GetWorkModeTimeout determines the current working mode of the sensor, using a custom timeout
+// NOTE: If mode caching is enabled, a known mode is returned without querying the device
+func (s *SDS011) GetWorkModeTimeout(timeout time.Duration) (WorkMode, error) {
+	return s.getWorkMode(context.Background(), timeout)
+}
+
+// GetWorkModeContext determines the current working mode of the sensor, aborting
+// if the context is cancelled (see GetWorkModeTimeout() regarding mode caching)
+func (s *SDS011) GetWorkModeContext(ctx context.Context) (WorkMode, error) {
+	return s.getWorkMode(ctx, s.timeout)
+}
+
+// RefreshWorkMode determines the current working mode of the sensor, always
+// querying the device (regardless of mode caching)
+func (s *SDS011) RefreshWorkMode() (WorkMode, error) {
+	return s.refreshWorkMode(context.Background(), s.timeout)
 }
 
 // SetWorkMode sets the current working mode of the sensor
 func (s *SDS011) SetWorkMode(mode WorkMode) error {
-	rxData, err := s.executeCommand(CommandSetWorkModePrefix + string(mode) + "00000000000000000000ffff")
+	return s.SetWorkModeTimeout(mode, s.timeout)
+}
+
+// SetWorkModeTimeout sets the current working mode of the sensor, using a custom timeout
+// NOTE: If mode caching is enabled, no command is sent if the device is known to
+// already be in the requested mode
+func (s *SDS011) SetWorkModeTimeout(mode WorkMode, timeout time.Duration) error {
+	return s.setWorkMode(context.Background(), mode, timeout)
+}
+
+// SetWorkModeContext sets the current working mode of the sensor, aborting if the
+// context is cancelled (see SetWorkModeTimeout() regarding mode caching)
+func (s *SDS011) SetWorkModeContext(ctx context.Context, mode WorkMode) error {
+	return s.setWorkMode(ctx, mode, s.timeout)
+}
+
+func (s *SDS011) setWorkMode(ctx context.Context, mode WorkMode, timeout time.Duration) error {
+	cachedMode, ok := s.cachedWorkMode()
+	if ok && s.useModeCache && cachedMode == mode {
+		return nil
+	}
+
+	// Invalidate the cached mode, the state of the device is unknown until confirmed
+	s.setCachedWorkMode("")
+
+	modeByte, err := EncodeWorkMode(mode)
+	if err != nil {
+		return err
+	}
+	rxData, err := s.executeCommand(ctx, timeout, CommandSleepWork, commandSet, modeByte)
 	if err != nil {
 		return err
 	}
 
-	if confirmedMode := WorkMode(hex.EncodeToString([]byte{rxData[4]})); confirmedMode != mode {
+	confirmedMode := DecodeWorkMode(rxData[4])
+	s.setCachedWorkMode(confirmedMode)
+	if confirmedMode == WorkModeActive && cachedMode != WorkModeActive {
+		s.markWoken()
+	}
+	if confirmedMode != mode {
 		return fmt.Errorf("unexpected work mode confirmation, want %s, have %s", mode, confirmedMode)
 	}
 
@@ -129,36 +430,114 @@ func (s *SDS011) SetWorkMode(mode WorkMode) error {
 
 // GetReportingMode determines the current reporting mode of the sensor
 func (s *SDS011) GetReportingMode() (ReportingMode, error) {
-	rxData, err := s.executeCommand(CommandGetReportingModePrefix + "0000000000000000000000ffff")
-	if err != nil {
-		return "", err
-	}
+	return s.GetReportingModeTimeout(s.timeout)
+}
+
+// GetReportingModeTimeout determines the current reporting mode of the sensor, using a custom timeout
+// NOTE: If mode caching is enabled, a known mode is returned without querying the device
+func (s *SDS011) GetReportingModeTimeout(timeout time.Duration) (ReportingMode, error) {
+	return s.getReportingMode(context.Background(), timeout)
+}
+
+// GetReportingModeContext determines the current reporting mode of the sensor,
+// aborting if the context is cancelled (see GetReportingModeTimeout() regarding
+// mode caching)
+func (s *SDS011) GetReportingModeContext(ctx context.Context) (ReportingMode, error) {
+	return s.getReportingMode(ctx, s.timeout)
+}
 
-	return ReportingMode(hex.EncodeToString([]byte{rxData[4]})), nil
+// RefreshReportingMode determines the current reporting mode of the sensor, always
+// querying the device (regardless of mode caching)
+func (s *SDS011) RefreshReportingMode() (ReportingMode, error) {
+	return s.refreshReportingMode(context.Background(), s.timeout)
 }
 
 // SetReportingMode sets the current reporting mode of the sensor
 func (s *SDS011) SetReportingMode(mode ReportingMode) error {
-	rxData, err := s.executeCommand(CommandSetReportingModePrefix + string(mode) + "00000000000000000000ffff")
+	return s.SetReportingModeTimeout(mode, s.timeout)
+}
+
+// SetReportingModeTimeout sets the current reporting mode of the sensor, using a custom timeout
+// NOTE: If mode caching is enabled, no command is sent if the device is known to
+// already be in the requested mode
+func (s *SDS011) SetReportingModeTimeout(mode ReportingMode, timeout time.Duration) error {
+	return s.setReportingMode(context.Background(), mode, timeout)
+}
+
+// SetReportingModeContext sets the current reporting mode of the sensor, aborting
+// if the context is cancelled (see SetReportingModeTimeout() regarding mode caching)
+func (s *SDS011) SetReportingModeContext(ctx context.Context, mode ReportingMode) error {
+	return s.setReportingMode(ctx, mode, s.timeout)
+}
+
+func (s *SDS011) setReportingMode(ctx context.Context, mode ReportingMode, timeout time.Duration) error {
+	if cachedMode, ok := s.cachedReportingMode(); ok && s.useModeCache && cachedMode == mode {
+		return nil
+	}
+
+	// Invalidate the cached mode, the state of the device is unknown until confirmed
+	s.setCachedReportingMode("")
+
+	modeByte, err := EncodeReportingMode(mode)
+	if err != nil {
+		return err
+	}
+	rxData, err := s.executeCommand(ctx, timeout, CommandReportingMode, commandSet, modeByte)
 	if err != nil {
 		return err
 	}
 
-	if confirmedMode := ReportingMode(hex.EncodeToString([]byte{rxData[4]})); confirmedMode != mode {
+	confirmedMode := DecodeReportingMode(rxData[4])
+	s.setCachedReportingMode(confirmedMode)
+	if confirmedMode != mode {
 		return fmt.Errorf("unexpected reporting mode confirmation, want %s, have %s", mode, confirmedMode)
 	}
 
 	return nil
 }
 
+// PersistQueryMode sets the reporting mode of the sensor to query mode and verifies
+// the setting by reading it back from the device
+// NOTE: The SDS011 stores the reporting mode in flash memory, i.e. the device
+// retains it across power cycles (no separate "save" command is required)
+func (s *SDS011) PersistQueryMode() error {
+	return s.persistReportingMode(ReportingModeQuery)
+}
+
+// PersistActiveMode sets the reporting mode of the sensor to active mode and
+// verifies the setting by reading it back from the device
+// NOTE: The SDS011 stores the reporting mode in flash memory, i.e. the device
+// retains it across power cycles (no separate "save" command is required)
+func (s *SDS011) PersistActiveMode() error {
+	return s.persistReportingMode(ReportingModeActive)
+}
+
 // GetWorkPeriod determines the current working period of the sensor (work for
 // 30 seconds, sleep for n minutes)
 func (s *SDS011) GetWorkPeriod() (int, error) {
-	rxData, err := s.executeCommand(CommandGetWorkPeriodPrefix + "0000000000000000000000ffff")
+	return s.GetWorkPeriodTimeout(s.timeout)
+}
+
+// GetWorkPeriodTimeout determines the current working period of the sensor, using
+// a custom timeout
+func (s *SDS011) GetWorkPeriodTimeout(timeout time.Duration) (int, error) {
+	return s.getWorkPeriod(context.Background(), timeout)
+}
+
+// GetWorkPeriodContext determines the current working period of the sensor,
+// aborting if the context is cancelled
+func (s *SDS011) GetWorkPeriodContext(ctx context.Context) (int, error) {
+	return s.getWorkPeriod(ctx, s.timeout)
+}
+
+func (s *SDS011) getWorkPeriod(ctx context.Context, timeout time.Duration) (int, error) {
+	rxData, err := s.executeCommand(ctx, timeout, CommandWorkingPeriod, commandGet)
 	if err != nil {
 		return 0, err
 	}
 
+	s.setCachedWorkPeriod(int(rxData[4]))
+
 	return int(rxData[4]), nil
 }
 
@@ -166,133 +545,629 @@ func (s *SDS011) GetWorkPeriod() (int, error) {
 // for n minutes)
 // NOTE: 0 denots continuous operation
 func (s *SDS011) SetWorkPeriod(delayMinutes int) error {
+	return s.SetWorkPeriodTimeout(delayMinutes, s.timeout)
+}
+
+// SetWorkPeriodTimeout sets the working period of the sensor, using a custom timeout
+func (s *SDS011) SetWorkPeriodTimeout(delayMinutes int, timeout time.Duration) error {
+	return s.setWorkPeriod(context.Background(), delayMinutes, timeout)
+}
+
+// SetWorkPeriodContext sets the working period of the sensor, aborting if the
+// context is cancelled
+func (s *SDS011) SetWorkPeriodContext(ctx context.Context, delayMinutes int) error {
+	return s.setWorkPeriod(ctx, delayMinutes, s.timeout)
+}
+
+func (s *SDS011) setWorkPeriod(ctx context.Context, delayMinutes int, timeout time.Duration) error {
 
 	if delayMinutes < WorkPeriodContinuous || delayMinutes > WorkPeriodMax {
 		return fmt.Errorf("requested working period out of limits, must be between 0 and 30 (minutes)")
 	}
 
-	rxData, err := s.executeCommand(CommandSetWorkPeriodPrefix + fmt.Sprintf("%02x", delayMinutes) + "00000000000000000000ffff")
+	rxData, err := s.executeCommand(ctx, timeout, CommandWorkingPeriod, commandSet, byte(delayMinutes))
 	if err != nil {
 		return err
 	}
 
-	if confirmedDelay := int(rxData[4]); confirmedDelay != delayMinutes {
+	confirmedDelay := int(rxData[4])
+	s.setCachedWorkPeriod(confirmedDelay)
+	if confirmedDelay != delayMinutes {
 		return fmt.Errorf("unexpected working period confirmation, want %d, have %d", delayMinutes, confirmedDelay)
 	}
 
 	return nil
 }
 
-// QueryData extract the current PM2.5 and PM10 values from the sensor (in query mode)
+// QueryData extract the current PM2.5 and PM10 values from the sensor
+// NOTE: If the device is known to be in active reporting mode (i.e. the mode was
+// last set / determined as such), the behavior depends on the configured policy
+// (see WithActiveModePolicy()): By default, the next data frame reported by the
+// device is returned instead of sending a query command (see QueryDataStrict())
 func (s *SDS011) QueryData() (*DataPoint, error) {
+	return s.QueryDataTimeout(s.timeout)
+}
+
+// QueryDataTimeout extract the current PM2.5 and PM10 values from the sensor,
+// using a custom timeout
+func (s *SDS011) QueryDataTimeout(timeout time.Duration) (*DataPoint, error) {
+	return s.queryDataAnyMode(context.Background(), timeout)
+}
+
+// QueryDataContext extract the current PM2.5 and PM10 values from the sensor,
+// aborting if the context is cancelled
+func (s *SDS011) QueryDataContext(ctx context.Context) (*DataPoint, error) {
+	return s.queryDataAnyMode(ctx, s.timeout)
+}
 
-	rxData, err := s.executeCommand("aab404000000000000000000000000ffff")
+// TryQueryData attempts to extract the current PM2.5 and PM10 values from the
+// sensor without blocking for long (see TryQueryTimeout), e.g. for polling from
+// an event loop. If no data is available in time, (nil, false, nil) is returned,
+// allowing to distinguish this case from an actual error
+func (s *SDS011) TryQueryData() (*DataPoint, bool, error) {
+	dataPoint, err := s.queryDataAnyMode(context.Background(), TryQueryTimeout)
 	if err != nil {
-		return nil, err
+		if errors.Is(err, ErrTimeout) {
+			return nil, false, nil
+		}
+		return nil, false, err
 	}
 
-	pm25, pm10, err := decodeSensorValues(rxData[2:6])
-	if err != nil {
-		return nil, err
+	return dataPoint, true, nil
+}
+
+// QueryDataStrict extract the current PM2.5 and PM10 values from the sensor (in
+// query mode), always sending a query command regardless of the reporting mode
+func (s *SDS011) QueryDataStrict() (*DataPoint, error) {
+	return s.queryData(context.Background(), s.timeout)
+}
+
+// ForceQuery extract the current PM2.5 and PM10 values from the sensor after
+// explicitly (re-)setting the work mode to active and the reporting mode to query
+// (with verification), disregarding any cached state. It is a heavier but more
+// reliable alternative to QueryData() if the state of the device is unknown (e.g.
+// for recovery after repeated failures).
+func (s *SDS011) ForceQuery(ctx context.Context) (*DataPoint, error) {
+
+	s.invalidateModeCache()
+
+	if err := s.SetWorkModeContext(ctx, WorkModeActive); err != nil {
+		return nil, fmt.Errorf("error setting active mode: %w", err)
+	}
+	if err := s.SetReportingModeContext(ctx, ReportingModeQuery); err != nil {
+		return nil, fmt.Errorf("error setting query reporting mode: %w", err)
 	}
 
-	// Create & return a data point
-	return &DataPoint{
-		TimeStamp: time.Now(),
-		PM25:      pm25,
-		PM10:      pm10,
-	}, nil
+	return s.queryData(ctx, s.timeout)
 }
 
 // WaitForData extract the current PM2.5 and PM10 values from the sensor (in continuous mode)
 // Data is returned upon reception from the serial endpoint
 func (s *SDS011) WaitForData() (*DataPoint, error) {
+	return s.WaitForDataTimeout(s.timeout)
+}
+
+// WaitForDataTimeout extract the current PM2.5 and PM10 values from the sensor (in
+// continuous mode), using a custom timeout (e.g. to wait for the first data after
+// activating the device)
+func (s *SDS011) WaitForDataTimeout(timeout time.Duration) (*DataPoint, error) {
+	return s.waitForData(context.Background(), timeout)
+}
+
+// WaitForDataContext extract the current PM2.5 and PM10 values from the sensor (in
+// continuous mode), aborting if the context is cancelled
+func (s *SDS011) WaitForDataContext(ctx context.Context) (*DataPoint, error) {
+	return s.waitForData(ctx, s.timeout)
+}
+
+// WaitForDataFrom extract the current PM2.5 and PM10 values from a specific sensor
+// on a shared bus (in continuous mode), discarding frames from all other devices
+// until a matching frame is received or the timeout is reached
+func (s *SDS011) WaitForDataFrom(id DeviceID) (*DataPoint, error) {
+	return s.waitForDataFrom(context.Background(), id, s.timeout)
+}
+
+// WaitForDataFromContext extract the current PM2.5 and PM10 values from a specific
+// sensor on a shared bus (in continuous mode), aborting if the context is cancelled
+func (s *SDS011) WaitForDataFromContext(ctx context.Context, id DeviceID) (*DataPoint, error) {
+	return s.waitForDataFrom(ctx, id, s.timeout)
+}
+
+// WaitForNData collects n consecutive data points from the sensor (in continuous
+// mode), e.g. for a burst average. Corrupt frames are skipped (up to a maximum
+// of maxCorruptFrames in total), each frame has to arrive within the timeout.
+func (s *SDS011) WaitForNData(ctx context.Context, n int) ([]DataPoint, error) {
+
+	res := make([]DataPoint, 0, n)
+	corrupt := 0
+	for len(res) < n {
+		dataPoint, err := s.waitForData(ctx, s.timeout)
+		if err != nil {
+			if isCorruptFrameError(err) && corrupt < maxCorruptFrames {
+				corrupt++
+				continue
+			}
+			return nil, fmt.Errorf("error reading data point %d of %d: %w", len(res)+1, n, err)
+		}
+		res = append(res, dataPoint.take())
+	}
+
+	return res, nil
+}
+
+// ReadRun collects a contiguous run of valid data points from the sensor (in
+// continuous mode), e.g. to characterize the stability of a link or to gather a
+// clean burst for averaging. The run starts with the first valid frame (awaited
+// using the default timeout, skipping corrupt frames) and ends upon a gap longer
+// than maxGap or a corrupt frame, in which case the run is returned. If the context
+// is cancelled, the run collected so far is returned alongside the context error.
+func (s *SDS011) ReadRun(ctx context.Context, maxGap time.Duration) ([]DataPoint, error) {
+
+	var res []DataPoint
+	corrupt := 0
+	for {
+		timeout := maxGap
+		if len(res) == 0 {
+			timeout = s.timeout
+		}
+
+		dataPoint, err := s.waitForData(ctx, timeout)
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return res, ctxErr
+			}
+			if len(res) == 0 {
+				if isCorruptFrameError(err) && corrupt < maxCorruptFrames {
+					corrupt++
+					continue
+				}
+				return nil, fmt.Errorf("error awaiting start of run: %w", err)
+			}
+			if errors.Is(err, ErrTimeout) || isCorruptFrameError(err) {
+				return res, nil
+			}
+			return res, err
+		}
+		res = append(res, dataPoint.take())
+	}
+}
+
+// CountFrames counts the valid data frames received from the sensor (in continuous
+// mode) within the provided window without decoding them, e.g. to diagnose the
+// cadence of the device or the quality of the link. Corrupt frames are skipped
+// by re-synchronizing (see WithResync()), regardless of the configuration.
+func (s *SDS011) CountFrames(d time.Duration) (int, error) {
+
+	deadline := time.Now().Add(d)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	count := 0
+	for {
+		if _, err := s.readDataFrameResync(ctx, time.Until(deadline)); err != nil {
+			if ctx.Err() != nil || errors.Is(err, ErrTimeout) {
+				return count, nil
+			}
+			if isCorruptFrameError(err) {
+				continue
+			}
+			return count, err
+		}
+		count++
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func (s *SDS011) persistReportingMode(mode ReportingMode) error {
+
+	// Bypass the mode cache in order to ensure that the command is actually sent
+	s.setCachedReportingMode("")
+	if err := s.SetReportingMode(mode); err != nil {
+		return err
+	}
+
+	confirmedMode, err := s.RefreshReportingMode()
+	if err != nil {
+		return fmt.Errorf("error verifying reporting mode: %w", err)
+	}
+	if confirmedMode != mode {
+		return fmt.Errorf("reporting mode was not persisted, want %s, have %s", mode, confirmedMode)
+	}
+
+	return nil
+}
+
+func (s *SDS011) getWorkMode(ctx context.Context, timeout time.Duration) (WorkMode, error) {
+	if mode, ok := s.cachedWorkMode(); ok && s.useModeCache {
+		return mode, nil
+	}
+
+	return s.refreshWorkMode(ctx, timeout)
+}
+
+func (s *SDS011) refreshWorkMode(ctx context.Context, timeout time.Duration) (WorkMode, error) {
+	rxData, err := s.executeCommand(ctx, timeout, CommandSleepWork, commandGet)
+	if err != nil {
+		return "", err
+	}
+
+	mode := DecodeWorkMode(rxData[4])
+	s.setCachedWorkMode(mode)
+
+	return mode, nil
+}
+
+func (s *SDS011) getReportingMode(ctx context.Context, timeout time.Duration) (ReportingMode, error) {
+	if mode, ok := s.cachedReportingMode(); ok && s.useModeCache {
+		return mode, nil
+	}
+
+	return s.refreshReportingMode(ctx, timeout)
+}
+
+func (s *SDS011) refreshReportingMode(ctx context.Context, timeout time.Duration) (ReportingMode, error) {
+	rxData, err := s.executeCommand(ctx, timeout, CommandReportingMode, commandGet)
+	if err != nil {
+		return "", err
+	}
+
+	mode := DecodeReportingMode(rxData[4])
+	s.setCachedReportingMode(mode)
+
+	return mode, nil
+}
+
+func (s *SDS011) queryDataAnyMode(ctx context.Context, timeout time.Duration) (*DataPoint, error) {
+	if s.autoSleep {
+		return s.queryDataAutoSleep(ctx, timeout)
+	}
 
-	rxData, err := s.readRawData()
+	return s.queryDataReportingMode(ctx, timeout)
+}
+
+// queryDataAutoSleep wakes the device (unless known to be awake), waits for the
+// air flow to settle, queries data and puts the device back to sleep
+func (s *SDS011) queryDataAutoSleep(ctx context.Context, timeout time.Duration) (*DataPoint, error) {
+
+	if mode, ok := s.cachedWorkMode(); !ok || mode != WorkModeActive {
+		if err := s.setWorkMode(ctx, WorkModeActive, timeout); err != nil {
+			return nil, fmt.Errorf("error waking device: %w", err)
+		}
+		if err := sleepContext(ctx, s.autoSleepSettle); err != nil {
+			return nil, err
+		}
+	}
+
+	dataPoint, err := s.queryDataReportingMode(ctx, timeout)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.setWorkMode(context.Background(), WorkModeSleep, timeout); err != nil {
+		return dataPoint, fmt.Errorf("error putting device to sleep: %w", err)
+	}
 
-	if err = validateRxData(rxData); err != nil {
+	return dataPoint, nil
+}
+
+func (s *SDS011) queryDataReportingMode(ctx context.Context, timeout time.Duration) (*DataPoint, error) {
+	if mode, ok := s.cachedReportingMode(); !ok || mode != ReportingModeActive {
+		return s.queryData(ctx, timeout)
+	}
+
+	switch s.activeModePolicy {
+	case ActiveModeError:
+		return nil, ErrActiveReportingMode
+	case ActiveModeAutoSwitch:
+		return s.queryDataAutoSwitch(ctx, timeout)
+	}
+
+	return s.waitForData(ctx, timeout)
+}
+
+// queryDataAutoSwitch temporarily switches the device to query reporting mode in
+// order to query data, restoring active reporting mode afterwards
+func (s *SDS011) queryDataAutoSwitch(ctx context.Context, timeout time.Duration) (*DataPoint, error) {
+
+	if err := s.setReportingMode(ctx, ReportingModeQuery, timeout); err != nil {
+		return nil, fmt.Errorf("error switching to query reporting mode: %w", err)
+	}
+
+	dataPoint, err := s.queryData(ctx, timeout)
+	// The prior mode is restored even if the context was cancelled in the meantime
+	if restoreErr := s.setReportingMode(context.Background(), ReportingModeActive, timeout); restoreErr != nil {
+		if err != nil {
+			return nil, err
+		}
+		return dataPoint, fmt.Errorf("error restoring active reporting mode: %w", restoreErr)
+	}
+
+	return dataPoint, err
+}
+
+func (s *SDS011) queryData(ctx context.Context, timeout time.Duration) (*DataPoint, error) {
+
+	rxData, err := s.executeCommand(ctx, timeout, CommandQueryData)
+	if err != nil {
 		return nil, err
 	}
 
-	pm25, pm10, err := decodeSensorValues(rxData[2:6])
+	dataPoint, err := s.newDataPoint(rxData)
 	if err != nil {
 		return nil, err
 	}
+	if s.discardWakeZero(dataPoint) {
+		return s.queryData(ctx, timeout)
+	}
 
-	// Create & return a data point
-	return &DataPoint{
-		TimeStamp: time.Now(),
-		PM25:      pm25,
-		PM10:      pm10,
-	}, nil
+	return dataPoint, nil
 }
 
-////////////////////////////////////////////////////////////////////////////////
+func (s *SDS011) waitForData(ctx context.Context, timeout time.Duration) (*DataPoint, error) {
 
-func (s *SDS011) executeCommand(hexCMD string) ([]byte, error) {
+	rxData, err := s.readDataFrame(ctx, timeout)
+	if err != nil {
+		return nil, err
+	}
 
-	txData, err := createCommand(hexCMD)
+	dataPoint, err := s.newDataPoint(rxData)
 	if err != nil {
 		return nil, err
 	}
+	if s.discardWakeZero(dataPoint) {
+		return s.waitForData(ctx, timeout)
+	}
 
-	if err := s.writeRawData(txData); err != nil {
+	return dataPoint, nil
+}
+
+// readDataFrame extracts a single (validated) data frame from the port
+func (s *SDS011) readDataFrame(ctx context.Context, timeout time.Duration) ([]byte, error) {
+
+	if s.resync {
+		return s.readDataFrameResync(ctx, timeout)
+	}
+
+	rxData, err := s.readFrame(ctx, timeout)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := expectPacket(rxData, s.framing, PacketKindData, 0); err != nil {
 		return nil, err
 	}
 
-	rxData, err := s.readRawData()
+	return rxData, nil
+}
+
+func (s *SDS011) waitForDataFrom(ctx context.Context, id DeviceID, timeout time.Duration) (*DataPoint, error) {
+
+	deadline := time.Now().Add(timeout)
+	for {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, ErrTimeout
+		}
+
+		dataPoint, err := s.waitForData(ctx, remaining)
+		if err != nil {
+
+			// A corrupt frame on a shared bus may originate from any device
+			if errors.Is(err, ErrChecksumMismatch) {
+				continue
+			}
+			return nil, err
+		}
+
+		if dataPoint.DeviceID == id {
+			return dataPoint, nil
+		}
+		if discarded := dataPoint.take(); s.onDiscard != nil {
+			s.onDiscard(discarded)
+		}
+	}
+}
+
+// newDataPoint creates a data point from a (validated) frame, applying the
+// calibration (if any)
+func (s *SDS011) newDataPoint(rxData []byte) (*DataPoint, error) {
+
+	dataPoint, err := s.newRawDataPoint(rxData)
 	if err != nil {
 		return nil, err
 	}
+	if s.calibration != nil {
+		dataPoint.PM25, dataPoint.PM10 = s.calibration.apply(dataPoint.PM25, dataPoint.PM10)
+	}
 
-	if err = validateRxData(rxData); err != nil {
+	return dataPoint, nil
+}
+
+// newRawDataPoint creates a data point from a (validated) frame
+func (s *SDS011) newRawDataPoint(rxData []byte) (*DataPoint, error) {
+
+	pm25, pm10, err := decodeSensorValues(rxData[2:6], s.scaleFactors, s.invalidAsNaN)
+	if err != nil {
 		return nil, err
 	}
 
-	return rxData, nil
+	// Create & return a data point
+	var dataPoint *DataPoint
+	if s.usePool {
+		dataPoint = newPooledDataPoint()
+	} else {
+		dataPoint = &DataPoint{}
+	}
+	dataPoint.TimeStamp = s.now()
+	dataPoint.PM25, dataPoint.PM10 = pm25, pm10
+	dataPoint.DeviceID = decodeDeviceID(rxData)
+	dataPoint.Labels = s.labels
+	dataPoint.WarmingUp = s.isWakeZero(rxData)
+	if s.rawCounts {
+		dataPoint.CountPM25, dataPoint.CountPM10, _ = DecodeCounts(rxData[2:6])
+	}
+
+	return dataPoint, nil
+}
+
+func (s *SDS011) executeCommand(ctx context.Context, timeout time.Duration, cmd Command, data ...byte) ([]byte, error) {
+	return s.executeCommandFor(ctx, timeout, DeviceIDAll, cmd, data...)
+}
+
+func (s *SDS011) executeCommandFor(ctx context.Context, timeout time.Duration, id DeviceID, cmd Command, data ...byte) ([]byte, error) {
+	rxData, _, err := s.executeCommandTimed(ctx, timeout, id, cmd, data...)
+	return rxData, err
+}
+
+// executeCommandTimed executes a command, additionally returning the time taken
+// by the device to reply (excluding any wait for the command slot, see
+// WithMinCommandInterval())
+func (s *SDS011) executeCommandTimed(ctx context.Context, timeout time.Duration, id DeviceID, cmd Command, data ...byte) ([]byte, time.Duration, error) {
+
+	txData, err := buildCommand(s.framing, id, cmd, data...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := s.awaitCommandSlot(ctx); err != nil {
+		return nil, 0, err
+	}
+	start := time.Now()
+	if err := s.writeRawData(txData); err != nil {
+		return nil, 0, err
+	}
+
+	rxData, err := s.readFrame(ctx, timeout)
+	if err != nil {
+		return nil, 0, err
+	}
+	elapsed := time.Since(start)
+
+	// Ensure that the correct kind of packet was received (see replyKind())
+	if _, err := expectPacket(rxData, s.framing, replyKind(cmd), cmd); err != nil {
+		return nil, 0, err
+	}
+
+	return rxData, elapsed, nil
 }
 
-const serialTimeout = 5 * time.Second
+// awaitCommandSlot waits until the minimum interval since the previous command
+// has elapsed (if configured), reserving the slot for the next command
+func (s *SDS011) awaitCommandSlot(ctx context.Context) error {
+	if s.minCommandInterval <= 0 {
+		return nil
+	}
 
-type serialReadResult struct {
-	data []byte
-	err  error
+	s.commandMutex.Lock()
+	slot := time.Now()
+	if next := s.lastCommand.Add(s.minCommandInterval); next.After(slot) {
+		slot = next
+	}
+	s.lastCommand = slot
+	s.commandMutex.Unlock()
+
+	return sleepContext(ctx, time.Until(slot))
 }
 
-// readRawData extracts data from the port
-func (s *SDS011) readRawData() ([]byte, error) {
+// readFrame reads and validates a single frame from the port, keeping track of
+// the respective metrics
+func (s *SDS011) readFrame(ctx context.Context, timeout time.Duration) ([]byte, error) {
 
-	dataChannel := make(chan serialReadResult, 1)
+	s.metrics.add(func(m *Metrics) { m.Reads++ })
 
-	go func() {
+	rxData, err := s.readRawData(ctx, timeout)
+	if err != nil {
+		if errors.Is(err, ErrTimeout) {
+			s.metrics.add(func(m *Metrics) { m.Timeouts++ })
+		}
+		return nil, err
+	}
 
-		// Wrap reader around port
-		reader := bufio.NewReader(s.port)
+	if err = validateRxData(rxData); err != nil {
+		if errors.Is(err, ErrChecksumMismatch) {
+			s.metrics.add(func(m *Metrics) { m.ChecksumFailures++ })
+		}
+		return nil, err
+	}
 
-		// Read full data line until termination signal is received
-		reply, err := reader.ReadBytes('\xab')
+	return rxData, nil
+}
 
-		dataChannel <- serialReadResult{
-			data: reply,
-			err:  err,
+// readDataFrameResync extracts a single data frame from the port, discarding
+// bytes one at a time (across several reads, if required) until a valid data
+// frame is found or maxResyncBytes have been discarded
+func (s *SDS011) readDataFrameResync(ctx context.Context, timeout time.Duration) ([]byte, error) {
+
+	s.metrics.add(func(m *Metrics) { m.Reads++ })
+
+	var buf []byte
+	discarded := 0
+	for {
+		rxData, err := s.readRawData(ctx, timeout)
+		if err != nil {
+			if errors.Is(err, ErrTimeout) {
+				s.metrics.add(func(m *Metrics) { m.Timeouts++ })
+			}
+			return nil, err
+		}
+		buf = append(buf, rxData...)
+
+		for len(buf) >= dataFrameLen {
+			if _, err := expectPacket(buf[:dataFrameLen], s.framing, PacketKindData, 0); err == nil {
+				return buf[:dataFrameLen], nil
+			} else if discarded == 0 {
+				if errors.Is(err, ErrChecksumMismatch) {
+					s.metrics.add(func(m *Metrics) { m.ChecksumFailures++ })
+				}
+				s.metrics.add(func(m *Metrics) { m.Resyncs++ })
+			}
+			buf = buf[1:]
+			if discarded++; discarded >= maxResyncBytes {
+				return nil, fmt.Errorf("%w: no valid data frame found after discarding %d bytes", ErrInvalidFrame, discarded)
+			}
 		}
-	}()
+	}
+}
+
+// readRawData extracts a single frame from the port
+func (s *SDS011) readRawData(ctx context.Context, timeout time.Duration) ([]byte, error) {
+
+	conn := s.getConn()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
 
 	select {
-	case res := <-dataChannel:
-		return res.data, res.err
-	case <-time.After(serialTimeout):
-		return nil, fmt.Errorf("timeout while reading from serial port (device in sleep mode?)")
+	case frame, ok := <-conn.frames:
+		if !ok {
+
+			// The reader terminates without error if the connection was closed
+			if conn.err == nil {
+				return nil, ErrClosed
+			}
+			return nil, conn.err
+		}
+		return frame, nil
+	case <-timer.C:
+		return nil, ErrTimeout
+	case <-ctx.Done():
+		return nil, ctx.Err()
 	}
 }
 
 // writeRawData writes data to the port
 func (s *SDS011) writeRawData(data []byte) error {
 
-	n, err := s.port.Write(data)
+	conn := s.getConn()
+
+	// Discard any stale frames to ensure that the next frame read is in reply to
+	// this command
+	conn.drain()
+
+	if s.trace != nil {
+		s.trace(TraceTX, data)
+	}
+
+	n, err := conn.port.Write(data)
 	if err != nil {
 		return err
 	}
@@ -306,13 +1181,21 @@ func (s *SDS011) writeRawData(data []byte) error {
 
 ////////////////////////////////////////////////////////////////////////////////
 
+// validateRxData ensures that a raw packet matches the length expected for its
+// kind (see frameLengths, unknown kinds are expected to match the length of a data
+// packet) and that its checksum is valid
 func validateRxData(data []byte) error {
-	if len(data) != expectedDataLen {
-		return fmt.Errorf("unexpected data length, want %d, have %d", expectedDataLen, len(data))
+	want := dataFrameLen
+	if len(data) > 1 {
+		want = frameLength(PacketKind(data[1]))
+	}
+	if len(data) != want {
+		return fmt.Errorf("%w: unexpected data length, want %d, have %d", ErrInvalidFrame, want, len(data))
 	}
 
-	if sum := calcChecksum(data[2:8]); sum != data[8] {
-		return fmt.Errorf("checksum mismatch, want %x, have %x", data[8], sum)
+	// The checksum covers all data bytes (excluding header, kind, checksum and tail)
+	if sum := calcChecksum(data[2 : want-2]); sum != data[want-2] {
+		return fmt.Errorf("%w, want %x, have %x", ErrChecksumMismatch, data[want-2], sum)
 	}
 
 	return nil
@@ -325,34 +1208,53 @@ func calcChecksum(data []byte) (sum byte) {
 	return
 }
 
-func createCommand(hexCMD string) ([]byte, error) {
+// ScaleFactors denotes the factors converting the raw counts reported by the
+// device to PM2.5 / PM10 concentrations (in μg / ㎥)
+type ScaleFactors struct {
+	PM25 float64
+	PM10 float64
+}
 
-	txData, err := hex.DecodeString(hexCMD)
-	if err != nil {
-		return nil, err
+// DefaultScaleFactors denotes the scale factors as defined by the datasheet
+var DefaultScaleFactors = ScaleFactors{
+	PM25: 0.1,
+	PM10: 0.1,
+}
+
+// DecodeCounts extracts the raw (unscaled) PM2.5 and PM10 counts from the data
+// bytes of a packet (see Packet.Payload)
+func DecodeCounts(rawData []byte) (uint16, uint16, error) {
+
+	if len(rawData) != 4 {
+		return 0, 0, fmt.Errorf("unexpected length of raw data, need exactly 4 bytes, have %d", len(rawData))
 	}
 
-	return append(txData, calcChecksum(txData[2:]), 171), nil
+	// Little endian, unsigned: 0-9999 counts map to 0-999.9 μg / ㎥
+	return binary.LittleEndian.Uint16(rawData[:2]), binary.LittleEndian.Uint16(rawData[2:]), nil
 }
 
 // decodeSensorValues extracts the floating-point representations of the PM2.5
-// and PM10 particle densities from the raw bytes
-func decodeSensorValues(rawData []byte) (float64, float64, error) {
+// and PM10 particle densities from the raw bytes (values beyond the measurement
+// range are reported as NaN if invalidAsNaN is set)
+func decodeSensorValues(rawData []byte, factors ScaleFactors, invalidAsNaN bool) (float64, float64, error) {
 
-	if len(rawData) != 4 {
-		return 0., 0., fmt.Errorf("unexpected length of raw data, need exactly 4 bytes, have %d", len(rawData))
+	count25, count10, err := DecodeCounts(rawData)
+	if err != nil {
+		return 0., 0., err
 	}
 
-	// Convert data to count (little endian)
-	var count25, count10 int16
-	buf := bytes.NewBuffer(rawData[:2])
-	if err := binary.Read(buf, binary.LittleEndian, &count25); err != nil {
-		return 0., 0., fmt.Errorf("error parsing PM2.5 value: %w", err)
-	}
-	buf = bytes.NewBuffer(rawData[2:])
-	if err := binary.Read(buf, binary.LittleEndian, &count10); err != nil {
-		return 0., 0., fmt.Errorf("error parsing PM10 value: %w", err)
+	pm25, pm10 := factors.PM25*float64(count25), factors.PM10*float64(count10)
+	if invalidAsNaN {
+
+		// The measurement range is defined in terms of the raw counts (avoiding
+		// rounding errors of the scaled values at the upper limit)
+		if count25 > maxCount {
+			pm25 = math.NaN()
+		}
+		if count10 > maxCount {
+			pm10 = math.NaN()
+		}
 	}
 
-	return 0.1 * float64(count25), 0.1 * float64(count10), nil
+	return pm25, pm10, nil
 }
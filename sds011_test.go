@@ -0,0 +1,119 @@
+package sds011
+
+import (
+	"math"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDecodeCounts(t *testing.T) {
+	for _, cs := range []struct {
+		raw              []byte
+		count25, count10 uint16
+	}{
+		{[]byte{0x00, 0x00, 0x00, 0x00}, 0, 0},
+		{[]byte{0x0f, 0x27, 0x0e, 0x27}, 9999, 9998},
+		{[]byte{0xff, 0x7f, 0x00, 0x80}, 0x7fff, 0x8000},
+		{[]byte{0x01, 0x80, 0x34, 0xc2}, 0x8001, 0xc234},
+		{[]byte{0xff, 0xff, 0xff, 0xff}, 0xffff, 0xffff},
+	} {
+		count25, count10, err := DecodeCounts(cs.raw)
+		if err != nil {
+			t.Fatalf("error decoding %x: %s", cs.raw, err)
+		}
+		if count25 != cs.count25 || count10 != cs.count10 {
+			t.Fatalf("unexpected counts for %x, want %d / %d, have %d / %d", cs.raw, cs.count25, cs.count10, count25, count10)
+		}
+	}
+
+	if _, _, err := DecodeCounts([]byte{0x00, 0x00, 0x00}); err == nil {
+		t.Fatalf("expected error for truncated raw data")
+	}
+}
+
+func TestDecodeSensorValuesHighConcentration(t *testing.T) {
+	for _, cs := range []struct {
+		raw        []byte
+		pm25, pm10 float64
+	}{
+		{[]byte{0x0f, 0x27, 0x0f, 0x27}, 999.9, 999.9},
+		{[]byte{0x00, 0x80, 0xff, 0xff}, 3276.8, 6553.5},
+	} {
+		pm25, pm10, err := decodeSensorValues(cs.raw, DefaultScaleFactors, false)
+		if err != nil {
+			t.Fatalf("error decoding %x: %s", cs.raw, err)
+		}
+		if math.Abs(pm25-cs.pm25) > 1e-9 || math.Abs(pm10-cs.pm10) > 1e-9 {
+			t.Fatalf("unexpected values for %x, want %v / %v, have %v / %v", cs.raw, cs.pm25, cs.pm10, pm25, pm10)
+		}
+	}
+
+	// Beyond the measurement range, values are reported as NaN if requested
+	pm25, pm10, err := decodeSensorValues([]byte{0x0f, 0x27, 0xff, 0xff}, DefaultScaleFactors, true)
+	if err != nil {
+		t.Fatalf("error decoding: %s", err)
+	}
+	if math.Abs(pm25-999.9) > 1e-9 || !math.IsNaN(pm10) {
+		t.Fatalf("unexpected values, want 999.9 / NaN, have %v / %v", pm25, pm10)
+	}
+}
+
+func TestMinCommandInterval(t *testing.T) {
+
+	const interval = 50 * time.Millisecond
+	d := newMockDevice()
+	s := newMockSensor(t, d, WithMinCommandInterval(interval))
+
+	// Fire commands back-to-back, both sequentially and concurrently
+	for i := 0; i < 3; i++ {
+		if _, err := s.GetFirmware(); err != nil {
+			t.Fatalf("error querying firmware: %s", err)
+		}
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.GetFirmware() // #nosec G104
+		}()
+	}
+	wg.Wait()
+
+	// Allow for some scheduling jitter between sending and receiving a command
+	times := d.commandTimes()
+	if len(times) != 6 {
+		t.Fatalf("unexpected number of commands received, want 6, have %d", len(times))
+	}
+	for i := 1; i < len(times); i++ {
+		if gap := times[i].Sub(times[i-1]); gap < interval-5*time.Millisecond {
+			t.Fatalf("commands %d / %d only %v apart, want at least %v", i-1, i, gap, interval)
+		}
+	}
+}
+
+func TestWithName(t *testing.T) {
+
+	labels := map[string]string{"room": "kitchen", NameLabel: "overridden"}
+	s := newMockSensor(t, newMockDevice(), WithLabels(labels), WithName("sensor-1"))
+
+	dataPoint, err := s.QueryData()
+	if err != nil {
+		t.Fatalf("error querying data: %s", err)
+	}
+	if dataPoint.Labels["room"] != "kitchen" || dataPoint.Labels[NameLabel] != "sensor-1" {
+		t.Fatalf("unexpected labels of data point: %v", dataPoint.Labels)
+	}
+	if labels[NameLabel] != "overridden" {
+		t.Fatalf("labels provided via WithLabels() were modified: %v", labels)
+	}
+	if name := s.Metrics().Name; name != "sensor-1" {
+		t.Fatalf("unexpected name in metrics, want sensor-1, have %s", name)
+	}
+
+	// Without an explicit name, the path of the device serves as name
+	if name := newMockSensor(t, newMockDevice()).Metrics().Name; name != "mock" {
+		t.Fatalf("unexpected default name in metrics, want mock, have %s", name)
+	}
+}
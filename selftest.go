@@ -0,0 +1,153 @@
+package sds011
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SelfTestStep denotes the result of a single step of a self-test
+type SelfTestStep struct {
+	Name     string
+	Passed   bool
+	Duration time.Duration
+	Err      error
+}
+
+// SelfTestReport denotes the results of all steps of a self-test
+type SelfTestReport struct {
+	Steps []SelfTestStep
+}
+
+// Passed determines if all steps of the self-test passed
+func (r *SelfTestReport) Passed() bool {
+	for _, step := range r.Steps {
+		if !step.Passed {
+			return false
+		}
+	}
+
+	return true
+}
+
+// String returns a human-readable summary of the self-test (one line per step),
+// fulfilling the Stringer interface
+func (r *SelfTestReport) String() string {
+	var sb strings.Builder
+	for _, step := range r.Steps {
+		result := "PASS"
+		if !step.Passed {
+			result = "FAIL"
+		}
+		fmt.Fprintf(&sb, "%s %-20s %v", result, step.Name, step.Duration.Round(time.Millisecond))
+		if step.Err != nil {
+			fmt.Fprintf(&sb, " (%s)", step.Err)
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// run performs a single step of the self-test and records its result (steps are
+// skipped once the context is cancelled)
+func (r *SelfTestReport) run(ctx context.Context, name string, fn func() error) {
+
+	start := time.Now()
+	err := ctx.Err()
+	if err == nil {
+		err = fn()
+	}
+
+	r.Steps = append(r.Steps, SelfTestStep{
+		Name:     name,
+		Passed:   err == nil,
+		Duration: time.Since(start),
+		Err:      err,
+	})
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// SelfTest exercises the device (which has to be awake) in order to verify that
+// it is fully operational: The firmware version is read, the reporting mode, work
+// period and work mode are toggled and read back and a sample is read. The state
+// of the device is restored at the end. An error is only returned if the initial
+// state cannot be determined or restoring it fails, the outcome of the individual
+// steps is listed in the report (see SelfTestReport.Passed()).
+// NOTE: A sleeping device only replies to work mode changes, hence all other
+// settings are toggled / restored while the device is awake and putting it to
+// sleep is verified by the confirmation of the command alone (see verifyWorkMode())
+func (s *SDS011) SelfTest(ctx context.Context) (*SelfTestReport, error) {
+
+	// Determine the initial state of the device
+	workMode, err := s.RefreshWorkMode()
+	if err != nil {
+		return nil, fmt.Errorf("error reading initial work mode: %w", err)
+	}
+	reportingMode, err := s.RefreshReportingMode()
+	if err != nil {
+		return nil, fmt.Errorf("error reading initial reporting mode: %w", err)
+	}
+	workPeriod, err := s.GetWorkPeriod()
+	if err != nil {
+		return nil, fmt.Errorf("error reading initial work period: %w", err)
+	}
+
+	report := &SelfTestReport{}
+	report.run(ctx, "firmware", func() error {
+		_, err := s.GetFirmware()
+		return err
+	})
+	report.run(ctx, "reporting mode", func() error {
+		toggled := ReportingModeActive
+		if reportingMode == ReportingModeActive {
+			toggled = ReportingModeQuery
+		}
+		if err := s.verifyReportingMode(toggled); err != nil {
+			return err
+		}
+		return s.verifyReportingMode(ReportingModeQuery)
+	})
+	report.run(ctx, "work period", func() error {
+		toggled := 1
+		if workPeriod == toggled {
+			toggled++
+		}
+		if err := s.verifyWorkPeriod(toggled); err != nil {
+			return err
+		}
+		return s.SetWorkPeriod(WorkPeriodContinuous)
+	})
+	report.run(ctx, "work mode (sleep)", func() error {
+		return s.verifyWorkMode(WorkModeSleep)
+	})
+	report.run(ctx, "work mode (active)", func() error {
+		return s.verifyWorkMode(WorkModeActive)
+	})
+	report.run(ctx, "sample", func() error {
+		dataPoint, err := s.queryData(ctx, s.timeout)
+		if err != nil {
+			return err
+		}
+		dataPoint.Release()
+		return nil
+	})
+
+	// Restore the initial state of the device (waking it up first, see above)
+	if err := s.SetWorkMode(WorkModeActive); err != nil {
+		return report, fmt.Errorf("error restoring work mode: %w", err)
+	}
+	if err := s.SetWorkPeriod(workPeriod); err != nil {
+		return report, fmt.Errorf("error restoring work period: %w", err)
+	}
+	if err := s.SetReportingMode(reportingMode); err != nil {
+		return report, fmt.Errorf("error restoring reporting mode: %w", err)
+	}
+	if err := s.SetWorkMode(workMode); err != nil {
+		return report, fmt.Errorf("error restoring work mode: %w", err)
+	}
+
+	return report, nil
+}
@@ -0,0 +1,29 @@
+package sds011
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSelfTest(t *testing.T) {
+
+	d := newMockDevice()
+	d.workPeriod = 3
+	s := newMockSensor(t, d, WithTimeout(100*time.Millisecond))
+
+	report, err := s.SelfTest(context.Background())
+	if err != nil {
+		t.Fatalf("error running self-test: %s", err)
+	}
+	if !report.Passed() {
+		t.Fatalf("self-test failed:\n%s", report)
+	}
+
+	// The initial state of the device must have been restored
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if !d.awake || d.activeReports || d.workPeriod != 3 {
+		t.Fatalf("state of device not restored (awake: %v, active reports: %v, work period: %d)", d.awake, d.activeReports, d.workPeriod)
+	}
+}
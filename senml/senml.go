@@ -0,0 +1,78 @@
+// Package senml provides a representation of SDS011 data points in the Sensor
+// Measurement Lists (SenML) JSON format (RFC 8428), as ingested by many IoT
+// platforms (e.g. via LwM2M).
+package senml
+
+import (
+	"encoding/json"
+	"math"
+	"time"
+
+	"github.com/fako1024/sds011"
+)
+
+const (
+
+	// NamePM25 denotes the name of the PM2.5 record
+	NamePM25 = "pm2_5"
+
+	// NamePM10 denotes the name of the PM10 record
+	NamePM10 = "pm10"
+
+	// UnitConcentration denotes the unit of all concentration records
+	UnitConcentration = "ug/m3"
+)
+
+// Record denotes a single SenML record
+type Record struct {
+	BaseName string   `json:"bn,omitempty"`
+	BaseTime float64  `json:"bt,omitempty"`
+	Name     string   `json:"n,omitempty"`
+	Unit     string   `json:"u,omitempty"`
+	Value    *float64 `json:"v,omitempty"`
+	Time     float64  `json:"t,omitempty"`
+}
+
+// Pack denotes a list of SenML records (with base fields carried by the first record)
+type Pack []Record
+
+// FromDataPoint converts a data point to a SenML pack, consisting of one record for
+// each of the PM2.5 and PM10 values. The base name (e.g. "urn:dev:sds011:a160:")
+// is prepended to the record names by the recipient, the base time is set to the
+// time stamp of the data point.
+// NOTE: NaN values (see sds011.WithInvalidAsNaN()) cannot be represented and are
+// omitted
+func FromDataPoint(p sds011.DataPoint, baseName string) Pack {
+
+	var pack Pack
+	for _, field := range []struct {
+		name  string
+		value float64
+	}{
+		{NamePM25, p.PM25},
+		{NamePM10, p.PM10},
+	} {
+		if math.IsNaN(field.value) {
+			continue
+		}
+
+		value := field.value
+		pack = append(pack, Record{
+			Name:  field.name,
+			Unit:  UnitConcentration,
+			Value: &value,
+		})
+	}
+
+	if len(pack) > 0 {
+		pack[0].BaseName = baseName
+		pack[0].BaseTime = float64(p.TimeStamp.UnixNano()) / float64(time.Second)
+	}
+
+	return pack
+}
+
+// Marshal returns the SenML JSON representation of a data point (see FromDataPoint())
+func Marshal(p sds011.DataPoint, baseName string) ([]byte, error) {
+	return json.Marshal(FromDataPoint(p, baseName))
+}
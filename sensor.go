@@ -0,0 +1,50 @@
+package sds011
+
+import (
+	"context"
+	"fmt"
+)
+
+// Sensor denotes a generic SDS011 compatible source of data points (e.g. a
+// physical device or a simulation), allowing application code to be decoupled
+// from the concrete implementation (e.g. for testing purposes)
+type Sensor interface {
+	GetFirmware() (string, error)
+	GetWorkMode() (WorkMode, error)
+	SetWorkMode(mode WorkMode) error
+	SetWorkModeContext(ctx context.Context, mode WorkMode) error
+	GetReportingMode() (ReportingMode, error)
+	SetReportingMode(mode ReportingMode) error
+	SetReportingModeContext(ctx context.Context, mode ReportingMode) error
+	GetWorkPeriod() (int, error)
+	SetWorkPeriod(delayMinutes int) error
+	SetWorkPeriodContext(ctx context.Context, delayMinutes int) error
+
+	QueryData() (*DataPoint, error)
+	QueryDataContext(ctx context.Context) (*DataPoint, error)
+	WaitForData() (*DataPoint, error)
+	WaitForDataContext(ctx context.Context) (*DataPoint, error)
+	Stream(ctx context.Context) (<-chan DataPoint, <-chan error)
+
+	Close() error
+	Shutdown() error
+}
+
+// shutdown puts a sensor to sleep and closes it, returning all errors encountered
+func shutdown(sensor Sensor) error {
+	var errs MultiError
+	if err := sensor.SetWorkMode(WorkModeSleep); err != nil {
+		errs = append(errs, fmt.Errorf("error setting sleep mode: %w", err))
+	}
+	if err := sensor.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("error closing sensor: %w", err))
+	}
+
+	return errs.ErrorOrNil()
+}
+
+// Compile-time checks that all sensors fulfill the Sensor interface
+var (
+	_ Sensor = &SDS011{}
+	_ Sensor = &SimulatedSensor{}
+)
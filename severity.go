@@ -0,0 +1,108 @@
+package sds011
+
+import "math"
+
+// Severity denotes an air quality category, consistent with the US EPA AQI bands
+type Severity int
+
+const (
+
+	// SeverityGood denotes good air quality
+	SeverityGood Severity = iota
+
+	// SeverityModerate denotes moderate air quality
+	SeverityModerate
+
+	// SeverityUnhealthySensitive denotes air quality unhealthy for sensitive groups
+	SeverityUnhealthySensitive
+
+	// SeverityUnhealthy denotes unhealthy air quality
+	SeverityUnhealthy
+
+	// SeverityVeryUnhealthy denotes very unhealthy air quality
+	SeverityVeryUnhealthy
+
+	// SeverityHazardous denotes hazardous air quality
+	SeverityHazardous
+
+	// SeverityUnknown denotes that the air quality cannot be categorized (i.e. if
+	// neither PM2.5 nor PM10 are valid, see WithInvalidAsNaN())
+	SeverityUnknown Severity = -1
+)
+
+// Upper (inclusive) concentration limits of the AQI bands (in μg / ㎥, US EPA
+// breakpoints as of 2024), the last band is open-ended
+var (
+	severityLimitsPM25 = []float64{9.0, 35.4, 55.4, 125.4, 225.4}
+	severityLimitsPM10 = []float64{54, 154, 254, 354, 424}
+)
+
+// String returns the human-readable name of the severity, fulfilling the Stringer interface
+func (s Severity) String() string {
+	switch s {
+	case SeverityGood:
+		return "Good"
+	case SeverityModerate:
+		return "Moderate"
+	case SeverityUnhealthySensitive:
+		return "Unhealthy for Sensitive Groups"
+	case SeverityUnhealthy:
+		return "Unhealthy"
+	case SeverityVeryUnhealthy:
+		return "Very Unhealthy"
+	case SeverityHazardous:
+		return "Hazardous"
+	}
+
+	return "Unknown"
+}
+
+// HexColor returns the canonical US EPA color of the severity (e.g. "#00E400")
+func (s Severity) HexColor() string {
+	switch s {
+	case SeverityGood:
+		return "#00E400"
+	case SeverityModerate:
+		return "#FFFF00"
+	case SeverityUnhealthySensitive:
+		return "#FF7E00"
+	case SeverityUnhealthy:
+		return "#FF0000"
+	case SeverityVeryUnhealthy:
+		return "#8F3F97"
+	case SeverityHazardous:
+		return "#7E0023"
+	}
+
+	return "#000000"
+}
+
+// Severity returns the air quality category of the data point, i.e. the worse
+// of the PM2.5 and PM10 categories (invalid / NaN values are disregarded)
+// NOTE: As per EPA guidance, PM2.5 is truncated to one decimal place and PM10
+// to an integer before categorization
+func (p *DataPoint) Severity() Severity {
+	sev25, sev10 := SeverityUnknown, SeverityUnknown
+	if !math.IsNaN(p.PM25) {
+		sev25 = severity(math.Floor(p.PM25*10+1e-9)/10, severityLimitsPM25)
+	}
+	if !math.IsNaN(p.PM10) {
+		sev10 = severity(math.Floor(p.PM10+1e-9), severityLimitsPM10)
+	}
+
+	if sev25 > sev10 {
+		return sev25
+	}
+
+	return sev10
+}
+
+func severity(value float64, limits []float64) Severity {
+	for i, limit := range limits {
+		if value <= limit {
+			return Severity(i)
+		}
+	}
+
+	return SeverityHazardous
+}
@@ -0,0 +1,60 @@
+package sds011
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSeverityBoundaries(t *testing.T) {
+	for _, cs := range []struct {
+		pm25, pm10 float64
+		expected   Severity
+	}{
+		{0, 0, SeverityGood},
+		{9.0, 54, SeverityGood},
+		{9.04, 54.9, SeverityGood},
+		{9.1, 0, SeverityModerate},
+		{0, 55, SeverityModerate},
+		{35.4, 154, SeverityModerate},
+		{35.5, 0, SeverityUnhealthySensitive},
+		{0, 155, SeverityUnhealthySensitive},
+		{55.4, 254, SeverityUnhealthySensitive},
+		{55.5, 0, SeverityUnhealthy},
+		{0, 255, SeverityUnhealthy},
+		{125.4, 354, SeverityUnhealthy},
+		{125.5, 0, SeverityVeryUnhealthy},
+		{0, 355, SeverityVeryUnhealthy},
+		{225.4, 424, SeverityVeryUnhealthy},
+		{225.5, 0, SeverityHazardous},
+		{0, 425, SeverityHazardous},
+		{999.9, 999.9, SeverityHazardous},
+
+		// The worse of both categories prevails
+		{9.1, 355, SeverityVeryUnhealthy},
+		{225.5, 0, SeverityHazardous},
+
+		// Invalid values are disregarded
+		{math.NaN(), 55, SeverityModerate},
+		{225.5, math.NaN(), SeverityHazardous},
+		{math.NaN(), math.NaN(), SeverityUnknown},
+	} {
+		p := DataPoint{PM25: cs.pm25, PM10: cs.pm10}
+		if sev := p.Severity(); sev != cs.expected {
+			t.Fatalf("unexpected severity for %v / %v, want %s, have %s", cs.pm25, cs.pm10, cs.expected, sev)
+		}
+	}
+}
+
+func TestSeverityHexColor(t *testing.T) {
+	colors := make(map[string]struct{})
+	for sev := SeverityGood; sev <= SeverityHazardous; sev++ {
+		color := sev.HexColor()
+		if len(color) != 7 || color[0] != '#' {
+			t.Fatalf("invalid color %q for severity %s", color, sev)
+		}
+		if _, exists := colors[color]; exists {
+			t.Fatalf("duplicate color %q for severity %s", color, sev)
+		}
+		colors[color] = struct{}{}
+	}
+}
@@ -0,0 +1,392 @@
+package sds011
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// SimulatedSensorConfig denotes the configuration of a SimulatedSensor
+type SimulatedSensorConfig struct {
+
+	// BasePM25 denotes the mean PM2.5 concentration (in μg / ㎥)
+	BasePM25 float64
+
+	// DiurnalAmplitude denotes the amplitude of the daily variation of the PM2.5
+	// concentration (in μg / ㎥), peaking in the early morning
+	DiurnalAmplitude float64
+
+	// Noise denotes the standard deviation of the random noise (in μg / ㎥)
+	Noise float64
+
+	// PM10Ratio denotes the ratio between PM10 and PM2.5 concentrations
+	PM10Ratio float64
+
+	// FrameInterval denotes the interval between frames in active reporting mode
+	FrameInterval time.Duration
+
+	// Seed denotes the seed of the random number generator (for reproducibility)
+	Seed int64
+
+	// Labels denotes labels attached to all generated data points (and must not
+	// be modified)
+	Labels map[string]string
+
+	// Clock denotes the source of the time stamps of all generated data points
+	// (default: time.Now), e.g. a fixed clock for deterministic tests
+	Clock func() time.Time
+
+	// Calibration denotes a calibration applied to all generated data points (if any)
+	Calibration *Calibration
+}
+
+// WithOptions returns a copy of the configuration with all options applicable to
+// a simulated sensor applied (WithLabels(), WithName(), WithClock() and
+// WithCalibration()), such that it can stand in for a physical device configured
+// via the same options. All other options concern the device / transport and
+// have no effect on a simulated sensor.
+func (c SimulatedSensorConfig) WithOptions(opts ...Option) SimulatedSensorConfig {
+	s := &SDS011{}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.applyNameLabel()
+
+	if s.labels != nil {
+		c.Labels = s.labels
+	}
+	if s.now != nil {
+		c.Clock = s.now
+	}
+	if s.calibration != nil {
+		c.Calibration = s.calibration
+	}
+
+	return c
+}
+
+// DefaultSimulatedSensorConfig denotes sane defaults for a SimulatedSensor
+var DefaultSimulatedSensorConfig = SimulatedSensorConfig{
+	BasePM25:         12.,
+	DiurnalAmplitude: 6.,
+	Noise:            1.5,
+	PM10Ratio:        1.6,
+	FrameInterval:    time.Second,
+	Seed:             1,
+}
+
+// SimulatedSensor denotes a synthetic sensor generating plausible data points
+// without any hardware (e.g. for demos or testing purposes)
+// It mimics the behavior of a physical device, i.e. only work mode changes are
+// answered while in sleep mode (all other calls time out, see ErrTimeout) and
+// data is only streamed in active reporting mode
+type SimulatedSensor struct {
+	cfg SimulatedSensorConfig
+	rng *rand.Rand
+
+	workMode      WorkMode
+	reportingMode ReportingMode
+	workPeriod    int
+	isClosed      bool
+
+	mutex sync.Mutex
+}
+
+// NewSimulatedSensor creates a new SimulatedSensor (in active work mode and
+// active reporting mode, mirroring the factory defaults of a physical device)
+func NewSimulatedSensor(cfg SimulatedSensorConfig) *SimulatedSensor {
+	if cfg.Clock == nil {
+		cfg.Clock = time.Now
+	}
+
+	return &SimulatedSensor{
+		cfg:           cfg,
+		rng:           rand.New(rand.NewSource(cfg.Seed)), // #nosec G404
+		workMode:      WorkModeActive,
+		reportingMode: ReportingModeActive,
+	}
+}
+
+// GetFirmware returns a fixed firmware version
+func (s *SimulatedSensor) GetFirmware() (string, error) {
+	if err := s.checkAvailable(); err != nil {
+		return "", err
+	}
+
+	return "2018-11-16", nil
+}
+
+// GetWorkMode returns the current (simulated) working mode
+func (s *SimulatedSensor) GetWorkMode() (WorkMode, error) {
+	if err := s.checkAvailable(); err != nil {
+		return "", err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.workMode, nil
+}
+
+// SetWorkMode sets the current (simulated) working mode
+func (s *SimulatedSensor) SetWorkMode(mode WorkMode) error {
+	return s.SetWorkModeContext(context.Background(), mode)
+}
+
+// SetWorkModeContext sets the current (simulated) working mode, aborting if the
+// context is cancelled
+func (s *SimulatedSensor) SetWorkModeContext(ctx context.Context, mode WorkMode) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := s.checkOpen(); err != nil {
+		return err
+	}
+	if mode != WorkModeSleep && mode != WorkModeActive {
+		return fmt.Errorf("invalid work mode %s", mode)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.workMode = mode
+
+	return nil
+}
+
+// GetReportingMode returns the current (simulated) reporting mode
+func (s *SimulatedSensor) GetReportingMode() (ReportingMode, error) {
+	if err := s.checkAvailable(); err != nil {
+		return "", err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.reportingMode, nil
+}
+
+// SetReportingMode sets the current (simulated) reporting mode
+func (s *SimulatedSensor) SetReportingMode(mode ReportingMode) error {
+	return s.SetReportingModeContext(context.Background(), mode)
+}
+
+// SetReportingModeContext sets the current (simulated) reporting mode, aborting
+// if the context is cancelled
+func (s *SimulatedSensor) SetReportingModeContext(ctx context.Context, mode ReportingMode) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := s.checkAvailable(); err != nil {
+		return err
+	}
+	if mode != ReportingModeActive && mode != ReportingModeQuery {
+		return fmt.Errorf("invalid reporting mode %s", mode)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.reportingMode = mode
+
+	return nil
+}
+
+// GetWorkPeriod returns the current (simulated) working period
+func (s *SimulatedSensor) GetWorkPeriod() (int, error) {
+	if err := s.checkAvailable(); err != nil {
+		return 0, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.workPeriod, nil
+}
+
+// SetWorkPeriod sets the current (simulated) working period
+// NOTE: The working period is only stored, it does not affect the simulation
+func (s *SimulatedSensor) SetWorkPeriod(delayMinutes int) error {
+	return s.SetWorkPeriodContext(context.Background(), delayMinutes)
+}
+
+// SetWorkPeriodContext sets the current (simulated) working period, aborting if
+// the context is cancelled
+func (s *SimulatedSensor) SetWorkPeriodContext(ctx context.Context, delayMinutes int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if delayMinutes < WorkPeriodContinuous || delayMinutes > WorkPeriodMax {
+		return fmt.Errorf("requested working period out of limits, must be between 0 and 30 (minutes)")
+	}
+	if err := s.checkAvailable(); err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.workPeriod = delayMinutes
+
+	return nil
+}
+
+// QueryData generates a simulated data point
+func (s *SimulatedSensor) QueryData() (*DataPoint, error) {
+	return s.QueryDataContext(context.Background())
+}
+
+// QueryDataContext generates a simulated data point, aborting if the context is
+// cancelled
+func (s *SimulatedSensor) QueryDataContext(ctx context.Context) (*DataPoint, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := s.checkAvailable(); err != nil {
+		return nil, err
+	}
+
+	return s.generate(s.cfg.Clock()), nil
+}
+
+// WaitForData generates a simulated data point after the frame interval has
+// elapsed (in active reporting mode)
+func (s *SimulatedSensor) WaitForData() (*DataPoint, error) {
+	return s.WaitForDataContext(context.Background())
+}
+
+// WaitForDataContext generates a simulated data point after the frame interval
+// has elapsed (in active reporting mode), aborting if the context is cancelled
+func (s *SimulatedSensor) WaitForDataContext(ctx context.Context) (*DataPoint, error) {
+	if err := s.checkAvailable(); err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	reportingMode := s.reportingMode
+	s.mutex.Unlock()
+
+	// In query reporting mode a physical device does not send any data
+	// by itself
+	if reportingMode != ReportingModeActive {
+		if err := sleepContext(ctx, DefaultTimeout); err != nil {
+			return nil, err
+		}
+		return nil, ErrTimeout
+	}
+
+	if err := sleepContext(ctx, s.cfg.FrameInterval); err != nil {
+		return nil, err
+	}
+
+	return s.generate(s.cfg.Clock()), nil
+}
+
+// Stream continuously emits simulated data points (in active reporting mode)
+// until the context is cancelled or the sensor is closed (see SDS011.Stream() for
+// sequence numbering)
+func (s *SimulatedSensor) Stream(ctx context.Context) (<-chan DataPoint, <-chan error) {
+	var seq uint64
+	return stream(ctx, func(ctx context.Context) (DataPoint, error) {
+		dataPoint, err := s.WaitForDataContext(ctx)
+		if err != nil {
+			return DataPoint{}, err
+		}
+
+		seq++
+		dataPoint.Seq = seq
+
+		return *dataPoint, nil
+	}, nil)
+}
+
+// Close closes the simulated sensor (all subsequent calls will fail)
+func (s *SimulatedSensor) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.isClosed = true
+
+	return nil
+}
+
+// Shutdown puts the simulated sensor to sleep and closes it
+func (s *SimulatedSensor) Shutdown() error {
+	return shutdown(s)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func (s *SimulatedSensor) checkOpen() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.isClosed {
+		return ErrClosed
+	}
+
+	return nil
+}
+
+// checkAvailable determines if the simulated sensor responds, mimicking a
+// physical device (which does not respond while in sleep mode)
+func (s *SimulatedSensor) checkAvailable() error {
+	if err := s.checkOpen(); err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.workMode == WorkModeSleep {
+		return ErrTimeout
+	}
+
+	return nil
+}
+
+// generate computes a noisy sine with diurnal variation (peaking at 6am)
+func (s *SimulatedSensor) generate(ts time.Time) *DataPoint {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	hourOfDay := float64(ts.Hour()) + float64(ts.Minute())/60.
+	diurnal := s.cfg.DiurnalAmplitude * math.Cos(2.*math.Pi*(hourOfDay-6.)/24.)
+
+	pm25 := s.cfg.BasePM25 + diurnal + s.cfg.Noise*s.rng.NormFloat64()
+	pm10 := s.cfg.PM10Ratio*pm25 + s.cfg.Noise*s.rng.NormFloat64()
+
+	dataPoint := &DataPoint{
+		TimeStamp: ts,
+		PM25:      simulatedValue(pm25),
+		PM10:      simulatedValue(pm10),
+		Labels:    s.cfg.Labels,
+	}
+	if s.cfg.Calibration != nil {
+		dataPoint.PM25, dataPoint.PM10 = s.cfg.Calibration.apply(dataPoint.PM25, dataPoint.PM10)
+	}
+
+	return dataPoint
+}
+
+// simulatedValue clamps a value to the range of the device and rounds it to its
+// resolution (0.1 μg / ㎥)
+func simulatedValue(v float64) float64 {
+	return math.Round(10.*math.Max(0., math.Min(v, 999.9))) / 10.
+}
+
+// sleepContext waits for the provided duration, aborting if the context is cancelled
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
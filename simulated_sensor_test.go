@@ -0,0 +1,185 @@
+package sds011
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fixedClock returns a clock advancing by one second per call, starting at a
+// fixed point in time
+func fixedClock() func() time.Time {
+	ts := time.Date(2024, 1, 1, 6, 0, 0, 0, time.UTC)
+	return func() time.Time {
+		ts = ts.Add(time.Second)
+		return ts
+	}
+}
+
+func newTestSimulatedSensor() *SimulatedSensor {
+	cfg := DefaultSimulatedSensorConfig
+	cfg.FrameInterval = time.Millisecond
+	cfg.Clock = fixedClock()
+
+	return NewSimulatedSensor(cfg)
+}
+
+func TestSimulatedSensorStream(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Identical seeds yield identical (reproducible) streams
+	streams := make([][]DataPoint, 2)
+	for i := range streams {
+		dataChan, _ := newTestSimulatedSensor().Stream(ctx)
+		for j := 0; j < 10; j++ {
+			streams[i] = append(streams[i], <-dataChan)
+		}
+	}
+
+	for i, p := range streams[0] {
+		if p.Seq != uint64(i+1) {
+			t.Fatalf("unexpected sequence number, want %d, have %d", i+1, p.Seq)
+		}
+		if p.PM25 < 0 || p.PM25 > MaxConcentration || p.PM10 < 0 || p.PM10 > MaxConcentration {
+			t.Fatalf("implausible data point %v", p)
+		}
+		if p.PM25 != streams[1][i].PM25 || p.PM10 != streams[1][i].PM10 || !p.TimeStamp.Equal(streams[1][i].TimeStamp) {
+			t.Fatalf("streams with identical seeds differ at index %d: %v vs. %v", i, p, streams[1][i])
+		}
+	}
+}
+
+func TestSimulatedSensorStreamClose(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s := newTestSimulatedSensor()
+	dataChan, errChan := s.Stream(ctx)
+	<-dataChan
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("error closing simulated sensor: %s", err)
+	}
+
+	// Closing the sensor terminates the stream (without cancelling the context)
+	timeout := time.After(time.Second)
+	for dataChan != nil || errChan != nil {
+		select {
+		case _, ok := <-dataChan:
+			if !ok {
+				dataChan = nil
+			}
+		case err, ok := <-errChan:
+			if !ok {
+				errChan = nil
+			} else if !errors.Is(err, ErrClosed) {
+				t.Fatalf("unexpected error, want %v, have %v", ErrClosed, err)
+			}
+		case <-timeout:
+			t.Fatalf("stream not terminated after closing the simulated sensor")
+		}
+	}
+}
+
+func TestSimulatedSensorSleep(t *testing.T) {
+
+	s := newTestSimulatedSensor()
+	if _, err := s.QueryData(); err != nil {
+		t.Fatalf("error querying awake sensor: %s", err)
+	}
+
+	// A sleeping sensor does not respond to anything but a work mode change
+	if err := s.SetWorkMode(WorkModeSleep); err != nil {
+		t.Fatalf("error putting sensor to sleep: %s", err)
+	}
+	if _, err := s.QueryData(); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("unexpected error querying sleeping sensor, want %v, have %v", ErrTimeout, err)
+	}
+	if _, err := s.GetWorkMode(); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("unexpected error reading work mode of sleeping sensor, want %v, have %v", ErrTimeout, err)
+	}
+	if err := s.SetReportingMode(ReportingModeQuery); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("unexpected error setting reporting mode of sleeping sensor, want %v, have %v", ErrTimeout, err)
+	}
+
+	if err := s.Shutdown(); err != nil {
+		t.Fatalf("error shutting down sensor: %s", err)
+	}
+	if _, err := s.QueryData(); err == nil {
+		t.Fatalf("expected error querying closed sensor")
+	}
+}
+
+func TestSimulatedSensorStreamQueryMode(t *testing.T) {
+
+	s := newTestSimulatedSensor()
+	if err := s.SetReportingMode(ReportingModeQuery); err != nil {
+		t.Fatalf("error setting query reporting mode: %s", err)
+	}
+
+	// No data is streamed in query reporting mode
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	dataChan, _ := s.Stream(ctx)
+	if _, ok := <-dataChan; ok {
+		t.Fatalf("unexpected data point streamed in query reporting mode")
+	}
+}
+
+func TestSimulatedSensorSetModeContext(t *testing.T) {
+
+	s := newTestSimulatedSensor()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := s.SetWorkModeContext(ctx, WorkModeSleep); !errors.Is(err, context.Canceled) {
+		t.Fatalf("unexpected error setting work mode with cancelled context, want %v, have %v", context.Canceled, err)
+	}
+	if err := s.SetReportingModeContext(ctx, ReportingModeQuery); !errors.Is(err, context.Canceled) {
+		t.Fatalf("unexpected error setting reporting mode with cancelled context, want %v, have %v", context.Canceled, err)
+	}
+
+	// Nothing must have been applied
+	if mode, err := s.GetWorkMode(); err != nil || mode != WorkModeActive {
+		t.Fatalf("unexpected work mode after cancelled change: %s (error: %v)", mode, err)
+	}
+	if mode, err := s.GetReportingMode(); err != nil || mode != ReportingModeActive {
+		t.Fatalf("unexpected reporting mode after cancelled change: %s (error: %v)", mode, err)
+	}
+}
+
+func TestSimulatedSensorConfigWithOptions(t *testing.T) {
+
+	calibration := Calibration{PM25Factor: 2, PM10Factor: 1, PM10Offset: 1}
+	clock := fixedClock()
+	cfg := DefaultSimulatedSensorConfig.WithOptions(WithLabels(map[string]string{"room": "kitchen"}),
+		WithName("test"), WithClock(clock), WithCalibration(calibration), WithTimeout(time.Second))
+	if cfg.Labels["room"] != "kitchen" || cfg.Labels[NameLabel] != "test" || cfg.Calibration == nil || cfg.Clock == nil {
+		t.Fatalf("options not applied to simulated sensor config: %+v", cfg)
+	}
+
+	// Data points match those of an uncalibrated sensor (with identical seed and
+	// clock) with the calibration applied
+	ref := DefaultSimulatedSensorConfig
+	ref.Clock = fixedClock()
+	want, err := NewSimulatedSensor(ref).WaitForData()
+	if err != nil {
+		t.Fatalf("error reading from reference simulated sensor: %s", err)
+	}
+	have, err := NewSimulatedSensor(cfg).WaitForData()
+	if err != nil {
+		t.Fatalf("error reading from simulated sensor: %s", err)
+	}
+	if have.PM25 != 2*want.PM25 || have.PM10 != want.PM10+1 || !have.TimeStamp.Equal(want.TimeStamp) || have.Labels[NameLabel] != "test" {
+		t.Fatalf("unexpected data point, want calibrated %v, have %v", want, have)
+	}
+
+	// Without options, the config remains unchanged
+	if cfg := DefaultSimulatedSensorConfig.WithOptions(); cfg.Labels != nil || cfg.Clock != nil || cfg.Calibration != nil {
+		t.Fatalf("unexpected simulated sensor config without options: %+v", cfg)
+	}
+}
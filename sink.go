@@ -0,0 +1,102 @@
+package sds011
+
+// Sink denotes a generic output for data points (e.g. a file, database or
+// remote endpoint)
+type Sink interface {
+
+	// Write outputs a single data point
+	Write(p DataPoint) error
+
+	// Close flushes any pending data and releases all resources of the sink
+	Close() error
+}
+
+// BatchSink denotes a Sink that can output several data points at once more
+// efficiently than writing them one by one
+type BatchSink interface {
+	Sink
+
+	// WriteBatch outputs several data points at once
+	WriteBatch(points []DataPoint) error
+}
+
+// writeBatch outputs several data points to a sink, using a batch write if
+// supported and returning the number of data points successfully written
+func writeBatch(sink Sink, points []DataPoint) (int, error) {
+	if batchSink, ok := sink.(BatchSink); ok {
+		if err := batchSink.WriteBatch(points); err != nil {
+			return 0, err
+		}
+		return len(points), nil
+	}
+
+	for i, p := range points {
+		if err := sink.Write(p); err != nil {
+			return i, err
+		}
+	}
+
+	return len(points), nil
+}
+
+// MultiSink denotes a Sink that tees all data points to several sinks
+type MultiSink struct {
+	sinks []Sink
+}
+
+// NewMultiSink creates a new MultiSink writing to all provided sinks
+func NewMultiSink(sinks ...Sink) *MultiSink {
+	return &MultiSink{
+		sinks: sinks,
+	}
+}
+
+// Write outputs a single data point to all sinks (a failing sink does not
+// prevent the data point from being written to the remaining ones)
+func (m *MultiSink) Write(p DataPoint) error {
+	var errs MultiError
+	for _, sink := range m.sinks {
+		if err := sink.Write(p); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs.ErrorOrNil()
+}
+
+// Close closes all sinks
+func (m *MultiSink) Close() error {
+	var errs MultiError
+	for _, sink := range m.sinks {
+		if err := sink.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs.ErrorOrNil()
+}
+
+// NopSink denotes a Sink that discards all data points (e.g. for testing purposes)
+type NopSink struct{}
+
+// Write discards the data point
+func (NopSink) Write(DataPoint) error {
+	return nil
+}
+
+// Close does nothing
+func (NopSink) Close() error {
+	return nil
+}
+
+// Compile-time checks that all sinks fulfill the Sink interface
+var (
+	_ Sink      = &MultiSink{}
+	_ Sink      = NopSink{}
+	_ Sink      = &BufferedWriter{}
+	_ Sink      = &WebhookSink{}
+	_ Sink      = &NDJSONWriter{}
+	_ Sink      = &FilterSink{}
+	_ BatchSink = &RemoteWriteSink{}
+	_ Sink      = &StatsDSink{}
+)
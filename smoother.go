@@ -0,0 +1,103 @@
+package sds011
+
+import (
+	"fmt"
+	"math"
+	"sync"
+)
+
+// Smoother denotes a filter smoothing PM2.5 and PM10 concentrations, either by
+// means of a simple moving average over a fixed number of data points or an
+// exponential moving average
+type Smoother struct {
+	window int
+	alpha  float64
+
+	points  []DataPoint
+	current DataPoint
+	valid   bool
+
+	mutex sync.Mutex
+}
+
+// NewSmoother creates a new Smoother computing the mean over the last window
+// data points
+func NewSmoother(window int) (*Smoother, error) {
+	if window < 1 {
+		return nil, fmt.Errorf("invalid smoothing window %d, must be at least 1", window)
+	}
+
+	return &Smoother{
+		window: window,
+	}, nil
+}
+
+// NewEMASmoother creates a new Smoother computing an exponential moving average
+// with smoothing factor alpha in (0, 1] (larger values put more weight on recent
+// data points, with 1 disabling smoothing altogether)
+func NewEMASmoother(alpha float64) (*Smoother, error) {
+	if !(alpha > 0 && alpha <= 1) {
+		return nil, fmt.Errorf("invalid smoothing factor %v, must be in (0, 1]", alpha)
+	}
+
+	return &Smoother{
+		alpha: alpha,
+	}, nil
+}
+
+// Add ingests a data point and returns the smoothed data point (carrying the
+// time stamp, device ID and labels of the ingested one)
+// NOTE: NaN values are skipped, i.e. they do not affect the smoothed value
+func (s *Smoother) Add(p DataPoint) DataPoint {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	smoothed := p
+	if s.alpha > 0 {
+		if s.valid {
+			smoothed.PM25 = s.ema(p.PM25, s.current.PM25)
+			smoothed.PM10 = s.ema(p.PM10, s.current.PM10)
+		}
+	} else {
+		if s.points = append(s.points, p); len(s.points) > s.window {
+			s.points = append(s.points[:0], s.points[1:]...)
+		}
+
+		smoothed.PM25, smoothed.PM10 = mean(s.points)
+	}
+
+	s.current, s.valid = smoothed, true
+
+	return smoothed
+}
+
+// Current returns the latest smoothed data point, ok is false if no data point
+// has been added yet
+func (s *Smoother) Current() (p DataPoint, ok bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.current, s.valid
+}
+
+// Reset discards all previously ingested data points
+func (s *Smoother) Reset() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.points, s.current, s.valid = nil, DataPoint{}, false
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// ema updates an exponential moving average, skipping NaN values
+func (s *Smoother) ema(val, prev float64) float64 {
+	if math.IsNaN(val) {
+		return prev
+	}
+	if math.IsNaN(prev) {
+		return val
+	}
+
+	return s.alpha*val + (1-s.alpha)*prev
+}
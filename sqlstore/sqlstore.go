@@ -0,0 +1,155 @@
+// Package sqlstore provides persistence of SDS011 data points in an SQL
+// database via the database/sql package. The driver is chosen by the caller,
+// the queries use `?` placeholders (as supported e.g. by SQLite / MySQL).
+// Labels are stored JSON encoded (empty if a data point does not carry any).
+package sqlstore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/fako1024/sds011"
+)
+
+const (
+	createTableStmt = `CREATE TABLE IF NOT EXISTS readings (
+	ts INTEGER NOT NULL,
+	pm25 REAL NOT NULL,
+	pm10 REAL NOT NULL,
+	device_id INTEGER NOT NULL,
+	labels TEXT NOT NULL
+)`
+	createIndexStmt = `CREATE INDEX IF NOT EXISTS readings_ts ON readings (ts)`
+
+	insertStmt = `INSERT INTO readings (ts, pm25, pm10, device_id, labels) VALUES (?, ?, ?, ?, ?)`
+	queryStmt  = `SELECT ts, pm25, pm10, device_id, labels FROM readings WHERE ts >= ? AND ts < ? ORDER BY ts`
+)
+
+// Compile-time check that the Store fulfills the sds011.BatchSink interface
+var _ sds011.BatchSink = &Store{}
+
+// Store denotes a persistent store of data points backed by an SQL database
+type Store struct {
+	db *sql.DB
+}
+
+// New creates a new Store on top of an existing database connection, creating
+// the readings table if required
+func New(db *sql.DB) (*Store, error) {
+
+	if _, err := db.Exec(createTableStmt); err != nil {
+		return nil, fmt.Errorf("error creating readings table: %w", err)
+	}
+	if _, err := db.Exec(createIndexStmt); err != nil {
+		return nil, fmt.Errorf("error creating readings index: %w", err)
+	}
+
+	return &Store{
+		db: db,
+	}, nil
+}
+
+// Insert persists a single data point
+func (s *Store) Insert(p sds011.DataPoint) error {
+	labels, err := encodeLabels(p.Labels)
+	if err != nil {
+		return err
+	}
+	if _, err := s.db.Exec(insertStmt, p.TimeStamp.UnixNano(), p.PM25, p.PM10, int64(p.DeviceID), labels); err != nil {
+		return fmt.Errorf("error inserting data point: %w", err)
+	}
+
+	return nil
+}
+
+// Write persists a single data point, fulfilling the sds011.Sink interface
+func (s *Store) Write(p sds011.DataPoint) error {
+	return s.Insert(p)
+}
+
+// WriteBatch persists several data points in a single transaction, fulfilling
+// the sds011.BatchSink interface
+func (s *Store) WriteBatch(points []sds011.DataPoint) error {
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+
+	stmt, err := tx.Prepare(insertStmt)
+	if err != nil {
+		tx.Rollback() // #nosec G104
+		return fmt.Errorf("error preparing insert statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, p := range points {
+		labels, err := encodeLabels(p.Labels)
+		if err != nil {
+			tx.Rollback() // #nosec G104
+			return err
+		}
+		if _, err := stmt.Exec(p.TimeStamp.UnixNano(), p.PM25, p.PM10, int64(p.DeviceID), labels); err != nil {
+			tx.Rollback() // #nosec G104
+			return fmt.Errorf("error inserting data point: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Close fulfills the sds011.Sink interface
+// NOTE: The underlying database is owned by the caller and is not closed
+func (s *Store) Close() error {
+	return nil
+}
+
+// Query returns all data points in the time interval [from, to), ordered by time
+func (s *Store) Query(from, to time.Time) ([]sds011.DataPoint, error) {
+
+	rows, err := s.db.Query(queryStmt, from.UnixNano(), to.UnixNano())
+	if err != nil {
+		return nil, fmt.Errorf("error querying data points: %w", err)
+	}
+	defer rows.Close()
+
+	var res []sds011.DataPoint
+	for rows.Next() {
+		var (
+			ts, id int64
+			labels string
+			p      sds011.DataPoint
+		)
+		if err := rows.Scan(&ts, &p.PM25, &p.PM10, &id, &labels); err != nil {
+			return nil, fmt.Errorf("error reading data point: %w", err)
+		}
+		p.TimeStamp, p.DeviceID = time.Unix(0, ts), sds011.DeviceID(id)
+		if labels != "" {
+			if err := json.Unmarshal([]byte(labels), &p.Labels); err != nil {
+				return nil, fmt.Errorf("error decoding labels of data point: %w", err)
+			}
+		}
+
+		res = append(res, p)
+	}
+
+	return res, rows.Err()
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// encodeLabels encodes the labels of a data point as JSON (empty if there are none)
+func encodeLabels(labels map[string]string) (string, error) {
+	if len(labels) == 0 {
+		return "", nil
+	}
+
+	data, err := json.Marshal(labels)
+	if err != nil {
+		return "", fmt.Errorf("error encoding labels of data point: %w", err)
+	}
+
+	return string(data), nil
+}
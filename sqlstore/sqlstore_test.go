@@ -0,0 +1,172 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fako1024/sds011"
+)
+
+func TestInsertQueryRoundTrip(t *testing.T) {
+
+	db, err := sql.Open(memDriverName, fmt.Sprintf("%s-%d", t.Name(), time.Now().UnixNano()))
+	if err != nil {
+		t.Fatalf("error opening database: %s", err)
+	}
+	defer db.Close() // #nosec G104
+
+	store, err := New(db)
+	if err != nil {
+		t.Fatalf("error creating store: %s", err)
+	}
+
+	// Store readings of several sensors, both individually and as batch
+	ts := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	points := []sds011.DataPoint{
+		{TimeStamp: ts, PM25: 1.5, PM10: 2.5, DeviceID: 0xa160, Labels: map[string]string{"room": "kitchen"}},
+		{TimeStamp: ts.Add(time.Second), PM25: 3.5, PM10: 4.5, DeviceID: 0xb270, Labels: map[string]string{"room": "office", "floor": "1"}},
+		{TimeStamp: ts.Add(2 * time.Second), PM25: 5.5, PM10: 6.5, DeviceID: 0xa160},
+	}
+	if err := store.Insert(points[0]); err != nil {
+		t.Fatalf("error inserting data point: %s", err)
+	}
+	if err := store.WriteBatch(points[1:]); err != nil {
+		t.Fatalf("error writing batch: %s", err)
+	}
+
+	res, err := store.Query(ts, ts.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("error querying data points: %s", err)
+	}
+	if len(res) != len(points) {
+		t.Fatalf("unexpected number of data points, want %d, have %d", len(points), len(res))
+	}
+	for i, p := range res {
+		if !p.TimeStamp.Equal(points[i].TimeStamp) || p.PM25 != points[i].PM25 || p.PM10 != points[i].PM10 ||
+			p.DeviceID != points[i].DeviceID || !reflect.DeepEqual(p.Labels, points[i].Labels) {
+			t.Fatalf("unexpected data point at index %d, want %v, have %v", i, points[i], p)
+		}
+	}
+
+	// Query only a sub-interval
+	if res, err = store.Query(ts.Add(time.Second), ts.Add(2*time.Second)); err != nil || len(res) != 1 || res[0].DeviceID != 0xb270 {
+		t.Fatalf("unexpected result querying sub-interval: %v (error: %v)", res, err)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// memDriverName denotes the name of a minimal in-memory database/sql driver
+// understanding exactly the statements issued by the Store
+const memDriverName = "sqlstore-mem"
+
+func init() {
+	sql.Register(memDriverName, &memDriver{dbs: make(map[string]*memDB)})
+}
+
+type memDriver struct {
+	dbs   map[string]*memDB
+	mutex sync.Mutex
+}
+
+type memDB struct {
+	rows  [][]driver.Value
+	mutex sync.Mutex
+}
+
+func (d *memDriver) Open(name string) (driver.Conn, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.dbs[name] == nil {
+		d.dbs[name] = &memDB{}
+	}
+
+	return &memConn{db: d.dbs[name]}, nil
+}
+
+type memConn struct {
+	db *memDB
+}
+
+func (c *memConn) Prepare(query string) (driver.Stmt, error) {
+	return &memStmt{db: c.db, query: query}, nil
+}
+
+func (c *memConn) Close() error              { return nil }
+func (c *memConn) Begin() (driver.Tx, error) { return c, nil }
+func (c *memConn) Commit() error             { return nil }
+func (c *memConn) Rollback() error           { return errors.New("rollback not supported") }
+
+type memStmt struct {
+	db    *memDB
+	query string
+}
+
+func (s *memStmt) Close() error  { return nil }
+func (s *memStmt) NumInput() int { return -1 }
+
+func (s *memStmt) Exec(args []driver.Value) (driver.Result, error) {
+	switch {
+	case strings.HasPrefix(s.query, "CREATE"):
+	case s.query == insertStmt:
+		s.db.mutex.Lock()
+		s.db.rows = append(s.db.rows, args)
+		s.db.mutex.Unlock()
+	default:
+		return nil, fmt.Errorf("unsupported statement: %s", s.query)
+	}
+
+	return driver.RowsAffected(1), nil
+}
+
+func (s *memStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if s.query != queryStmt {
+		return nil, fmt.Errorf("unsupported query: %s", s.query)
+	}
+
+	s.db.mutex.Lock()
+	defer s.db.mutex.Unlock()
+
+	from, to := args[0].(int64), args[1].(int64)
+	res := &memRows{}
+	for _, row := range s.db.rows {
+		if ts := row[0].(int64); ts >= from && ts < to {
+			res.rows = append(res.rows, row)
+		}
+	}
+	sort.Slice(res.rows, func(i, j int) bool {
+		return res.rows[i][0].(int64) < res.rows[j][0].(int64)
+	})
+
+	return res, nil
+}
+
+type memRows struct {
+	rows [][]driver.Value
+}
+
+func (r *memRows) Columns() []string {
+	return []string{"ts", "pm25", "pm10", "device_id", "labels"}
+}
+
+func (r *memRows) Close() error { return nil }
+
+func (r *memRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+
+	return nil
+}
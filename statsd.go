@@ -0,0 +1,227 @@
+package sds011
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// statsDMaxPacketSize denotes the maximum size of a single UDP packet sent to
+// StatsD (chosen to avoid fragmentation on common networks)
+const statsDMaxPacketSize = 1432
+
+// StatsDSink denotes a Sink that emits the PM2.5 / PM10 values of each data point
+// as gauges (<prefix>pm25 / <prefix>pm10) to a StatsD endpoint via UDP. Tags (as
+// well as the labels of the data points) are attached using the DogStatsD tag
+// format. Metrics are buffered and sent periodically (or once a packet is full).
+// Invalid (NaN) values are not emitted.
+// NOTE: UDP is fire-and-forget, errors while sending are counted (see SendErrors())
+// but never reported to the caller
+type StatsDSink struct {
+	conn          net.Conn
+	prefix        string
+	tags          string
+	flushInterval time.Duration
+
+	buf        bytes.Buffer
+	sendErrors uint64
+	mutex      sync.Mutex
+
+	done     chan struct{}
+	wg       sync.WaitGroup
+	isClosed bool
+}
+
+// StatsDOption denotes a functional option for a StatsDSink
+type StatsDOption func(*StatsDSink)
+
+// WithStatsDPrefix sets the prefix of all metric names (default: "sds011.")
+func WithStatsDPrefix(prefix string) StatsDOption {
+	return func(s *StatsDSink) {
+		s.prefix = prefix
+	}
+}
+
+// WithStatsDTags sets tags attached to all metrics
+func WithStatsDTags(tags map[string]string) StatsDOption {
+	return func(s *StatsDSink) {
+		s.tags = formatStatsDTags(tags)
+	}
+}
+
+// WithStatsDFlushInterval sets the interval in which buffered metrics are sent
+// (default: 1s, 0 sends each data point immediately)
+func WithStatsDFlushInterval(interval time.Duration) StatsDOption {
+	return func(s *StatsDSink) {
+		s.flushInterval = interval
+	}
+}
+
+// NewStatsDSink creates a new StatsDSink sending to the provided address
+// (host:port)
+func NewStatsDSink(addr string, opts ...StatsDOption) (*StatsDSink, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to StatsD endpoint %s: %w", addr, err)
+	}
+
+	s := &StatsDSink{
+		conn:          conn,
+		prefix:        "sds011.",
+		flushInterval: time.Second,
+		done:          make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.flushInterval > 0 {
+		s.wg.Add(1)
+		go s.flushLoop()
+	}
+
+	return s, nil
+}
+
+// Write buffers the gauges of a single data point
+func (s *StatsDSink) Write(p DataPoint) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.isClosed {
+		return fmt.Errorf("cannot write to closed StatsD sink")
+	}
+
+	tags := s.tags
+	if labelTags := formatStatsDTags(p.Labels); labelTags != "" {
+		if tags != "" {
+			tags += ","
+		}
+		tags += labelTags
+	}
+
+	for _, metric := range []struct {
+		name  string
+		value float64
+	}{
+		{"pm25", p.PM25},
+		{"pm10", p.PM10},
+	} {
+
+		// Gauges cannot represent invalid values, hence these are skipped (see
+		// WithInvalidAsNaN())
+		if math.IsNaN(metric.value) {
+			continue
+		}
+
+		line := s.prefix + metric.name + ":" + strconv.FormatFloat(metric.value, 'f', -1, 64) + "|g"
+		if tags != "" {
+			line += "|#" + tags
+		}
+
+		// Send the current packet if the line does not fit anymore
+		if s.buf.Len() > 0 && s.buf.Len()+1+len(line) > statsDMaxPacketSize {
+			s.flush()
+		}
+		if s.buf.Len() > 0 {
+			s.buf.WriteByte('\n')
+		}
+		s.buf.WriteString(line)
+	}
+
+	if s.flushInterval <= 0 {
+		s.flush()
+	}
+
+	return nil
+}
+
+// Flush sends all buffered metrics
+func (s *StatsDSink) Flush() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.flush()
+}
+
+// SendErrors returns the number of packets that could not be sent
+func (s *StatsDSink) SendErrors() uint64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.sendErrors
+}
+
+// Close sends all buffered metrics and closes the connection
+func (s *StatsDSink) Close() error {
+	s.mutex.Lock()
+	if s.isClosed {
+		s.mutex.Unlock()
+		return nil
+	}
+	s.isClosed = true
+	close(s.done)
+	s.mutex.Unlock()
+
+	s.wg.Wait()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.flush()
+
+	return s.conn.Close()
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func (s *StatsDSink) flushLoop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.Flush()
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *StatsDSink) flush() {
+	if s.buf.Len() == 0 {
+		return
+	}
+
+	if _, err := s.conn.Write(s.buf.Bytes()); err != nil {
+		s.sendErrors++
+	}
+	s.buf.Reset()
+}
+
+// formatStatsDTags formats tags in the DogStatsD format (sorted by key)
+func formatStatsDTags(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	for i, k := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(k + ":" + tags[k])
+	}
+
+	return buf.String()
+}
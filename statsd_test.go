@@ -0,0 +1,40 @@
+package sds011
+
+import (
+	"math"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestStatsDSinkSkipsNaN(t *testing.T) {
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening for StatsD packets: %s", err)
+	}
+	defer conn.Close() // #nosec G104
+
+	sink, err := NewStatsDSink(conn.LocalAddr().String(), WithStatsDPrefix("air."))
+	if err != nil {
+		t.Fatalf("error creating StatsD sink: %s", err)
+	}
+	defer sink.Close() // #nosec G104
+
+	if err := sink.Write(DataPoint{PM25: math.NaN(), PM10: 12.5}); err != nil {
+		t.Fatalf("error writing data point: %s", err)
+	}
+	sink.Flush()
+
+	buf := make([]byte, statsDMaxPacketSize)
+	if err := conn.SetReadDeadline(time.Now().Add(time.Second)); err != nil {
+		t.Fatalf("error setting read deadline: %s", err)
+	}
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("error reading StatsD packet: %s", err)
+	}
+	if packet := string(buf[:n]); packet != "air.pm10:12.5|g" {
+		t.Fatalf("unexpected StatsD packet, want %q, have %q", "air.pm10:12.5|g", packet)
+	}
+}
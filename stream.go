@@ -0,0 +1,221 @@
+package sds011
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+
+	// streamErrBufferSize denotes the number of errors buffered on the error channel
+	// of a stream (further errors are dropped if they are not consumed)
+	streamErrBufferSize = 16
+
+	// streamReconnectBackoff denotes the initial delay before attempting to reconnect
+	// a stream (doubled after each failed attempt)
+	streamReconnectBackoff = time.Second
+
+	// streamReconnectBackoffMax denotes the maximum delay between reconnect attempts
+	streamReconnectBackoffMax = time.Minute
+
+	// streamWorkPeriodMargin denotes the additional time to wait for a frame on
+	// top of the working period before reporting a timeout
+	streamWorkPeriodMargin = 30 * time.Second
+)
+
+// ReconnectEvent is emitted on the error channel of a stream after the connection
+// to the device was successfully re-established
+type ReconnectEvent struct {
+	Cause    error // Error that caused the reconnect
+	Attempts int   // Number of attempts required to reconnect
+}
+
+// Error returns a description of the event, fulfilling the error interface
+func (e *ReconnectEvent) Error() string {
+	return fmt.Sprintf("reconnected after %d attempt(s), cause: %s", e.Attempts, e.Cause)
+}
+
+// Stream continuously reads data from the sensor (in active reporting mode) and
+// emits each data point on the returned data channel until the context is cancelled
+// Transient errors (e.g. timeouts or corrupt frames) are emitted on the error
+// channel without terminating the stream (and are dropped if the error channel
+// is not consumed). If the connection to the device breaks (e.g. io.EOF on a
+// network connection), the stream reconnects with exponential backoff and emits
+// a *ReconnectEvent once successful. The stream only terminates if the context
+// is cancelled or the sensor is closed, in which case both channels are closed.
+// Each data point carries a sequence number (see DataPoint.Seq), incremented per
+// emitted data point and reset on each call. Since data points are never dropped
+// by the stream itself, gaps in the sequence are caused by the consumer.
+// Teardown: Cancelling the context aborts waiting for the next frame immediately
+// (no read has to time out), i.e. both channels are closed promptly. The only
+// exception is an ongoing reconnect attempt, which is completed first (bounded
+// by DefaultDialTimeout for network connections). The port itself is read by a
+// background reader owned by the connection, which is not affected by the
+// cancellation and keeps reading until the sensor is closed (see Close()).
+// NOTE: If a working period is configured (see SetWorkPeriod()), the device only
+// reports a frame once per period. The stream takes this into account and only
+// reports a timeout if a frame is overdue (the period is determined from the
+// last known setting or queried from the device when the stream is started).
+func (s *SDS011) Stream(ctx context.Context) (<-chan DataPoint, <-chan error) {
+
+	s.prepareStream(ctx)
+	estimator := s.newSampleTimeEstimator()
+
+	var seq uint64
+	return stream(ctx, func(ctx context.Context) (DataPoint, error) {
+		dataPoint, err := s.waitForData(ctx, s.streamTimeout())
+		if err != nil {
+			return DataPoint{}, err
+		}
+
+		res := dataPoint.take()
+		seq++
+		res.Seq = seq
+		estimator.annotate(&res)
+
+		return res, nil
+	}, s.Reconnect)
+}
+
+// CalibratedDataPoint denotes a data point both in its raw form (as reported
+// by the device) and with the calibration applied
+type CalibratedDataPoint struct {
+	Raw        DataPoint
+	Calibrated DataPoint
+}
+
+// StreamRaw continuously reads data from the sensor (in active reporting mode)
+// in the same way as Stream(), but emits both the raw and the calibrated values
+// of each data point (e.g. to compare against a reference instrument while
+// determining a calibration, see WithCalibration())
+func (s *SDS011) StreamRaw(ctx context.Context) (<-chan CalibratedDataPoint, <-chan error) {
+
+	s.prepareStream(ctx)
+	estimator := s.newSampleTimeEstimator()
+
+	var seq uint64
+	return stream(ctx, func(ctx context.Context) (CalibratedDataPoint, error) {
+		rxData, err := s.readDataFrame(ctx, s.streamTimeout())
+		if err != nil {
+			return CalibratedDataPoint{}, err
+		}
+		dataPoint, err := s.newRawDataPoint(rxData)
+		if err != nil {
+			return CalibratedDataPoint{}, err
+		}
+
+		seq++
+		res := CalibratedDataPoint{
+			Raw: dataPoint.take(),
+		}
+		res.Raw.Seq = seq
+		estimator.annotate(&res.Raw)
+		res.Calibrated = res.Raw
+		if s.calibration != nil {
+			res.Calibrated = s.calibration.Apply(res.Raw)
+		}
+
+		return res, nil
+	}, s.Reconnect)
+}
+
+// prepareStream determines the working period if unknown (best effort, the
+// timeout defaults to the one of the sensor otherwise)
+func (s *SDS011) prepareStream(ctx context.Context) {
+	if _, ok := s.cachedWorkPeriod(); !ok {
+		s.getWorkPeriod(ctx, s.timeout) // #nosec G104
+	}
+}
+
+// streamTimeout determines the maximum time to wait for the next frame while
+// streaming, taking into account the working period (if known)
+func (s *SDS011) streamTimeout() time.Duration {
+	delayMinutes, ok := s.cachedWorkPeriod()
+	if !ok || delayMinutes == WorkPeriodContinuous {
+		return s.timeout
+	}
+
+	if timeout := time.Duration(delayMinutes)*time.Minute + streamWorkPeriodMargin; timeout > s.timeout {
+		return timeout
+	}
+
+	return s.timeout
+}
+
+// stream runs a generic stream loop around a function waiting for data, using
+// the reconnect function (if any) to recover from connection errors
+func stream[T any](ctx context.Context, waitFn func(context.Context) (T, error), reconnectFn func() error) (<-chan T, <-chan error) {
+
+	dataChan := make(chan T)
+	errChan := make(chan error, streamErrBufferSize)
+
+	emitErr := func(err error) {
+		select {
+		case errChan <- err:
+		default:
+		}
+	}
+
+	go func() {
+		defer close(errChan)
+		defer close(dataChan)
+
+		for ctx.Err() == nil {
+			dataPoint, err := waitFn(ctx)
+			if err != nil {
+
+				// Do not report errors caused by the cancellation itself
+				if ctx.Err() != nil {
+					return
+				}
+				emitErr(err)
+
+				if IsTransportError(err) {
+					if reconnectFn == nil || !reconnectStream(ctx, err, reconnectFn, emitErr) {
+						return
+					}
+				}
+				continue
+			}
+
+			select {
+			case dataChan <- dataPoint:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return dataChan, errChan
+}
+
+// reconnectStream attempts to reconnect with exponential backoff until successful
+// (returning true), the context is cancelled or the sensor is closed
+func reconnectStream(ctx context.Context, cause error, reconnectFn func() error, emitErr func(error)) bool {
+
+	backoff := streamReconnectBackoff
+	for attempts := 1; ; attempts++ {
+		if err := sleepContext(ctx, backoff); err != nil {
+			return false
+		}
+
+		err := reconnectFn()
+		if err == nil {
+			emitErr(&ReconnectEvent{
+				Cause:    cause,
+				Attempts: attempts,
+			})
+			return true
+		}
+		if errors.Is(err, ErrClosed) {
+			return false
+		}
+		emitErr(err)
+
+		if backoff *= 2; backoff > streamReconnectBackoffMax {
+			backoff = streamReconnectBackoffMax
+		}
+	}
+}
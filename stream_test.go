@@ -0,0 +1,58 @@
+package sds011
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStreamCancelLatency(t *testing.T) {
+
+	// The device is silent (query reporting mode), hence the stream is waiting
+	// for a frame when it is cancelled
+	s := newMockSensor(t, newMockDevice(), WithTimeout(5*time.Second))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	dataChan, errChan := s.Stream(ctx)
+	time.Sleep(100 * time.Millisecond)
+
+	start := time.Now()
+	cancel()
+	for range dataChan {
+	}
+	for err := range errChan {
+		t.Fatalf("unexpected error on cancelled stream: %s", err)
+	}
+	if latency := time.Since(start); latency > 250*time.Millisecond {
+		t.Fatalf("stream terminated %v after cancellation, want less than 250ms", latency)
+	}
+}
+
+func TestStreamTerminatesOnClose(t *testing.T) {
+
+	d := newMockDevice()
+	d.activeReports, d.frameInterval = true, 5*time.Millisecond
+	s := newMockSensor(t, d)
+
+	dataChan, errChan := s.Stream(context.Background())
+	if _, ok := <-dataChan; !ok {
+		t.Fatalf("stream terminated before emitting any data point")
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("error closing sensor: %s", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		for range dataChan {
+		}
+		for range errChan {
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * streamReconnectBackoff):
+		t.Fatalf("stream did not terminate after closing the sensor")
+	}
+}
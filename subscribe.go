@@ -0,0 +1,168 @@
+package sds011
+
+import (
+	"context"
+	"sync"
+)
+
+// subscriberBufferSize denotes the number of data points buffered per subscriber
+const subscriberBufferSize = 16
+
+// subscription denotes the channels of a single subscriber
+type subscription struct {
+	data chan DataPoint
+	errs chan error
+}
+
+// close closes both channels of the subscription
+func (s subscription) close() {
+	close(s.data)
+	close(s.errs)
+}
+
+// subscribers keeps track of all consumers of data points distributed by a single
+// reader (see Subscribe())
+type subscribers struct {
+	subs   map[uint64]subscription
+	nextID uint64
+	reader uint64
+	cancel context.CancelFunc
+
+	sync.Mutex
+}
+
+// Subscribe returns channels receiving all data points read from the sensor (in
+// active reporting mode) and all errors encountered while reading (in the same
+// way as Stream()), allowing several consumers to share the port: A single
+// internal reader (see Stream()) is started with the first subscription and
+// stopped once the last subscriber has unsubscribed via the returned function.
+// Both channels are closed upon unsubscribing or if the reader terminates (e.g.
+// on an unrecoverable connection error, which is reported beforehand).
+// NOTE: Data points and errors are buffered per subscriber, consumers that fall
+// behind miss data points / errors instead of blocking all others. The sensor
+// must not be read by other means while subscriptions exist.
+func (s *SDS011) Subscribe() (<-chan DataPoint, <-chan error, func()) {
+	s.subscribers.Lock()
+
+	if s.subscribers.subs == nil {
+		s.subscribers.subs = make(map[uint64]subscription)
+	}
+
+	id := s.subscribers.nextID
+	s.subscribers.nextID++
+	sub := subscription{
+		data: make(chan DataPoint, subscriberBufferSize),
+		errs: make(chan error, streamErrBufferSize),
+	}
+	s.subscribers.subs[id] = sub
+
+	var (
+		ctx    context.Context
+		reader uint64
+	)
+	if s.subscribers.cancel == nil {
+		ctx, s.subscribers.cancel = context.WithCancel(context.Background())
+		s.subscribers.reader++
+		reader = s.subscribers.reader
+	}
+	s.subscribers.Unlock()
+
+	// Start the reader (if required) without holding the lock since starting a
+	// stream may involve communication with the device (see Stream())
+	if ctx != nil {
+		dataChan, errChan := s.Stream(ctx)
+		go s.distribute(dataChan, errChan, reader)
+	}
+
+	var once sync.Once
+	return sub.data, sub.errs, func() {
+		once.Do(func() {
+			s.unsubscribe(id)
+		})
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// distribute forwards all data points and errors of a stream to all subscribers,
+// closing all remaining subscriptions once the stream terminates (unless the
+// reader has been stopped / replaced in the meantime)
+func (s *SDS011) distribute(dataChan <-chan DataPoint, errChan <-chan error, reader uint64) {
+
+	for dataChan != nil || errChan != nil {
+		select {
+		case dataPoint, ok := <-dataChan:
+			if !ok {
+				dataChan = nil
+				continue
+			}
+			s.forward(reader, func(sub subscription) {
+				select {
+				case sub.data <- dataPoint:
+				default:
+				}
+			})
+		case err, ok := <-errChan:
+			if !ok {
+				errChan = nil
+				continue
+			}
+			s.forward(reader, func(sub subscription) {
+				select {
+				case sub.errs <- err:
+				default:
+				}
+			})
+		}
+	}
+
+	s.subscribers.Lock()
+	defer s.subscribers.Unlock()
+
+	if !s.isCurrentReader(reader) {
+		return
+	}
+	for id, sub := range s.subscribers.subs {
+		sub.close()
+		delete(s.subscribers.subs, id)
+	}
+	s.subscribers.cancel()
+	s.subscribers.cancel = nil
+}
+
+// forward passes an item on to all subscribers (if the reader is still active)
+func (s *SDS011) forward(reader uint64, fn func(subscription)) {
+	s.subscribers.Lock()
+	defer s.subscribers.Unlock()
+
+	if !s.isCurrentReader(reader) {
+		return
+	}
+	for _, sub := range s.subscribers.subs {
+		fn(sub)
+	}
+}
+
+// isCurrentReader determines if a reader is still active (i.e. neither stopped
+// nor replaced), the caller must hold the lock
+func (s *SDS011) isCurrentReader(reader uint64) bool {
+	return s.subscribers.cancel != nil && s.subscribers.reader == reader
+}
+
+// unsubscribe removes a subscriber, stopping the reader after the last one
+func (s *SDS011) unsubscribe(id uint64) {
+	s.subscribers.Lock()
+	defer s.subscribers.Unlock()
+
+	sub, exists := s.subscribers.subs[id]
+	if !exists {
+		return
+	}
+	sub.close()
+	delete(s.subscribers.subs, id)
+
+	if len(s.subscribers.subs) == 0 && s.subscribers.cancel != nil {
+		s.subscribers.cancel()
+		s.subscribers.cancel = nil
+	}
+}
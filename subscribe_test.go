@@ -0,0 +1,43 @@
+package sds011
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSubscribe(t *testing.T) {
+
+	d := newMockDevice()
+	d.activeReports, d.frameInterval = true, 5*time.Millisecond
+	s := newMockSensor(t, d)
+
+	dataChan1, errChan1, unsubscribe1 := s.Subscribe()
+	dataChan2, errChan2, unsubscribe2 := s.Subscribe()
+	defer unsubscribe1()
+	defer unsubscribe2()
+
+	for _, dataChan := range []<-chan DataPoint{dataChan1, dataChan2} {
+		select {
+		case _, ok := <-dataChan:
+			if !ok {
+				t.Fatalf("subscription terminated before receiving any data point")
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no data point received by subscriber")
+		}
+	}
+
+	// Closing the sensor terminates the reader, which is reported to all subscribers
+	if err := s.Close(); err != nil {
+		t.Fatalf("error closing sensor: %s", err)
+	}
+	for _, errChan := range []<-chan error{errChan1, errChan2} {
+		var lastErr error
+		for err := range errChan {
+			lastErr = err
+		}
+		if !IsTransportError(lastErr) {
+			t.Fatalf("unexpected termination of subscription, want transport error, have %v", lastErr)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package sds011
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TraceDirection denotes the direction of a traced frame
+type TraceDirection string
+
+const (
+
+	// TraceTX denotes a frame sent to the device
+	TraceTX = TraceDirection("TX")
+
+	// TraceRX denotes a frame received from the device
+	TraceRX = TraceDirection("RX")
+)
+
+// TraceFunc denotes a function called for each raw frame sent to / received from
+// the device (the frame must not be modified or retained)
+type TraceFunc func(dir TraceDirection, frame []byte)
+
+// captureHeader denotes the first line of each capture, identifying its format
+const captureHeader = "# sds011 capture v1 (<RFC3339 timestamp> <TX|RX> <hex frame>)"
+
+// Recorder denotes a writer for captures of raw frames, using a simple line-based
+// format (one timestamped frame per line)
+type Recorder struct {
+	w     io.Writer
+	buf   *bufio.Writer
+	err   error
+	mutex sync.Mutex
+}
+
+// NewRecorder creates a new Recorder writing to the provided writer, its Trace
+// method can be used as trace function for an SDS011 sensor:
+//
+//	rec := sds011.NewRecorder(f)
+//	sensor, err := sds011.New("/dev/ttyUSB0", sds011.WithTrace(rec.Trace))
+func NewRecorder(w io.Writer) *Recorder {
+	r := &Recorder{
+		w:   w,
+		buf: bufio.NewWriter(w),
+	}
+	_, r.err = fmt.Fprintln(r.buf, captureHeader)
+
+	return r
+}
+
+// Trace records a single frame, fulfilling the TraceFunc signature
+// Write errors are retained and returned by Close()
+func (r *Recorder) Trace(dir TraceDirection, frame []byte) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.err != nil {
+		return
+	}
+	_, r.err = fmt.Fprintf(r.buf, "%s %s %s\n", time.Now().Format(time.RFC3339Nano), dir, hex.EncodeToString(frame))
+}
+
+// Close flushes all buffered frames and closes the underlying writer (if it
+// supports it), returning the first error encountered while recording
+func (r *Recorder) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := r.buf.Flush(); err != nil && r.err == nil {
+		r.err = err
+	}
+	if closer, ok := r.w.(io.Closer); ok {
+		if err := closer.Close(); err != nil && r.err == nil {
+			r.err = err
+		}
+	}
+
+	return r.err
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// CapturedFrame denotes a single frame of a capture
+type CapturedFrame struct {
+	TimeStamp time.Time
+	Direction TraceDirection
+	Frame     []byte
+}
+
+// CaptureReader denotes a reader for captures written by a Recorder
+type CaptureReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// NewCaptureReader creates a new CaptureReader reading from the provided reader
+func NewCaptureReader(r io.Reader) *CaptureReader {
+	return &CaptureReader{
+		scanner: bufio.NewScanner(r),
+	}
+}
+
+// Next returns the next frame of the capture, returning io.EOF once the end of
+// the capture is reached
+func (c *CaptureReader) Next() (*CapturedFrame, error) {
+	for c.scanner.Scan() {
+		c.line++
+
+		line := strings.TrimSpace(c.scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid capture line %d: expected 3 fields, have %d", c.line, len(fields))
+		}
+
+		ts, err := time.Parse(time.RFC3339Nano, fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp in capture line %d: %w", c.line, err)
+		}
+
+		dir := TraceDirection(fields[1])
+		if dir != TraceTX && dir != TraceRX {
+			return nil, fmt.Errorf("invalid direction in capture line %d: %s", c.line, fields[1])
+		}
+
+		frame, err := hex.DecodeString(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid frame in capture line %d: %w", c.line, err)
+		}
+
+		return &CapturedFrame{
+			TimeStamp: ts,
+			Direction: dir,
+			Frame:     frame,
+		}, nil
+	}
+
+	if err := c.scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return nil, io.EOF
+}
@@ -0,0 +1,195 @@
+package sds011
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// TrendDirection denotes the direction of a concentration trend
+type TrendDirection int
+
+const (
+
+	// TrendStable denotes a (mostly) constant concentration
+	TrendStable TrendDirection = iota
+
+	// TrendRising denotes an increasing concentration
+	TrendRising
+
+	// TrendFalling denotes a decreasing concentration
+	TrendFalling
+)
+
+// String returns the human-readable name of the trend direction, fulfilling the Stringer interface
+func (d TrendDirection) String() string {
+	switch d {
+	case TrendRising:
+		return "rising"
+	case TrendFalling:
+		return "falling"
+	}
+
+	return "stable"
+}
+
+// TrendConfig denotes the configuration of a Trend
+type TrendConfig struct {
+
+	// Window denotes the time window (relative to the latest data point) to
+	// consider for the trend
+	Window time.Duration
+
+	// OutlierSigma denotes the number of standard deviations from the initial
+	// regression beyond which data points are ignored (0 disables outlier removal)
+	OutlierSigma float64
+
+	// StableThreshold denotes the absolute slope (in μg / ㎥ per hour) below which
+	// a trend is considered stable
+	StableThreshold float64
+}
+
+// DefaultTrendConfig denotes sane defaults for a Trend
+var DefaultTrendConfig = TrendConfig{
+	Window:          time.Hour,
+	OutlierSigma:    3.,
+	StableThreshold: 1.,
+}
+
+// Trend denotes a linear regression of PM2.5 and PM10 concentrations over a
+// sliding time window
+type Trend struct {
+	cfg    TrendConfig
+	points []DataPoint
+	mutex  sync.Mutex
+}
+
+// NewTrend creates a new Trend
+func NewTrend(cfg TrendConfig) *Trend {
+	return &Trend{
+		cfg: cfg,
+	}
+}
+
+// Add ingests a data point, discarding all data points outside of the window
+// Data points are expected to be added in chronological order
+func (t *Trend) Add(p DataPoint) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.points = append(t.points, p)
+
+	cutoff := p.TimeStamp.Add(-t.cfg.Window)
+	i := 0
+	for i < len(t.points) && t.points[i].TimeStamp.Before(cutoff) {
+		i++
+	}
+	t.points = append(t.points[:0], t.points[i:]...)
+}
+
+// Slope returns the current slopes of the PM2.5 and PM10 concentrations (in
+// μg / ㎥ per hour), ok is false if there are insufficient (non-NaN) data points
+func (t *Trend) Slope() (pm25, pm10 float64, ok bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if len(t.points) < 2 {
+		return 0., 0., false
+	}
+
+	// Skip NaN values (individually per field)
+	var x25, y25, x10, y10 []float64
+	for _, p := range t.points {
+		x := p.TimeStamp.Sub(t.points[0].TimeStamp).Hours()
+		if !math.IsNaN(p.PM25) {
+			x25, y25 = append(x25, x), append(y25, p.PM25)
+		}
+		if !math.IsNaN(p.PM10) {
+			x10, y10 = append(x10, x), append(y10, p.PM10)
+		}
+	}
+
+	pm25, ok25 := robustSlope(x25, y25, t.cfg.OutlierSigma)
+	pm10, ok10 := robustSlope(x10, y10, t.cfg.OutlierSigma)
+
+	return pm25, pm10, ok25 && ok10
+}
+
+// Direction returns the current directions of the PM2.5 and PM10 trends
+func (t *Trend) Direction() (pm25, pm10 TrendDirection) {
+	slope25, slope10, ok := t.Slope()
+	if !ok {
+		return TrendStable, TrendStable
+	}
+
+	return t.direction(slope25), t.direction(slope10)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func (t *Trend) direction(slope float64) TrendDirection {
+	if slope > t.cfg.StableThreshold {
+		return TrendRising
+	}
+	if slope < -t.cfg.StableThreshold {
+		return TrendFalling
+	}
+
+	return TrendStable
+}
+
+// robustSlope performs a linear regression, then repeats it after removing all
+// points with a residual beyond sigma standard deviations
+func robustSlope(x, y []float64, sigma float64) (float64, bool) {
+	slope, intercept, ok := linearRegression(x, y)
+	if !ok || sigma <= 0 {
+		return slope, ok
+	}
+
+	var sumSq float64
+	residuals := make([]float64, len(x))
+	for i := range x {
+		residuals[i] = y[i] - (slope*x[i] + intercept)
+		sumSq += residuals[i] * residuals[i]
+	}
+	limit := sigma * math.Sqrt(sumSq/float64(len(x)))
+
+	var xf, yf []float64
+	for i := range x {
+		if math.Abs(residuals[i]) <= limit {
+			xf, yf = append(xf, x[i]), append(yf, y[i])
+		}
+	}
+	if len(xf) == len(x) {
+		return slope, ok
+	}
+
+	slope, _, ok = linearRegression(xf, yf)
+	return slope, ok
+}
+
+// linearRegression performs a least-squares linear fit
+func linearRegression(x, y []float64) (slope, intercept float64, ok bool) {
+	n := float64(len(x))
+	if n < 2 {
+		return 0., 0., false
+	}
+
+	var sumX, sumY, sumXX, sumXY float64
+	for i := range x {
+		sumX += x[i]
+		sumY += y[i]
+		sumXX += x[i] * x[i]
+		sumXY += x[i] * y[i]
+	}
+
+	denom := n*sumXX - sumX*sumX
+	if denom == 0 {
+		return 0., 0., false
+	}
+
+	slope = (n*sumXY - sumX*sumY) / denom
+	intercept = (sumY - slope*sumX) / n
+
+	return slope, intercept, true
+}
@@ -0,0 +1,112 @@
+package sds011
+
+import "math"
+
+const (
+
+	// ScaleMilligramsPerCubicMeter denotes the factor to convert concentrations
+	// from μg / ㎥ (as reported by the device) to mg / ㎥
+	ScaleMilligramsPerCubicMeter = 1e-3
+
+	// ScaleNanogramsPerCubicMeter denotes the factor to convert concentrations
+	// from μg / ㎥ (as reported by the device) to ng / ㎥
+	ScaleNanogramsPerCubicMeter = 1e3
+
+	// ScaleGramsPerCubicMeter denotes the factor to convert concentrations from
+	// μg / ㎥ (as reported by the device) to g / ㎥
+	ScaleGramsPerCubicMeter = 1e-6
+)
+
+// Scale returns a copy of the data point with the PM2.5 / PM10 values multiplied
+// by the provided factor (e.g. for unit conversion), leaving the original as is
+func (p DataPoint) Scale(factor float64) DataPoint {
+	p.PM25 *= factor
+	p.PM10 *= factor
+
+	return p
+}
+
+// MilligramsPerCubicMeter returns a copy of the data point with the PM2.5 / PM10
+// values converted to mg / ㎥
+func (p DataPoint) MilligramsPerCubicMeter() DataPoint {
+	return p.Scale(ScaleMilligramsPerCubicMeter)
+}
+
+// Add returns the field-wise sum of the PM2.5 / PM10 values of two data points,
+// carrying the time stamp and metadata of the later one (e.g. as building block
+// for aggregations)
+// NOTE: NaN values propagate, i.e. the sum of a field is NaN if either value is
+func (p DataPoint) Add(other DataPoint) DataPoint {
+	res := p
+	if other.TimeStamp.After(p.TimeStamp) {
+		res = other
+	}
+	res.PM25 = p.PM25 + other.PM25
+	res.PM10 = p.PM10 + other.PM10
+
+	return res
+}
+
+// DivideScalar returns a copy of the data point with the PM2.5 / PM10 values
+// divided by n (e.g. to compute a mean from a sum, see Add())
+func (p DataPoint) DivideScalar(n float64) DataPoint {
+	p.PM25 /= n
+	p.PM10 /= n
+
+	return p
+}
+
+// Quantize returns a copy of the data point with the PM2.5 / PM10 values rounded
+// to the nearest multiple of step (halfway values are rounded away from zero),
+// e.g. to reduce the precision of publicly shared data. A step <= 0 leaves the
+// values as is, NaN values are retained.
+func (p DataPoint) Quantize(step float64) DataPoint {
+	if step <= 0 {
+		return p
+	}
+
+	p.PM25 = quantize(p.PM25, step)
+	p.PM10 = quantize(p.PM10, step)
+
+	return p
+}
+
+// quantize rounds a value to the nearest multiple of step, avoiding representation
+// errors for fractional steps (e.g. 0.1 yielding 12.3 instead of 12.300000000000001)
+func quantize(val, step float64) float64 {
+	if step < 1 {
+		inv := 1 / step
+		return math.Round(val*inv) / inv
+	}
+
+	return math.Round(val/step) * step
+}
+
+// PM25Int returns the PM2.5 value rounded to the nearest whole μg / ㎥ (rounding
+// half to even, e.g. 12.5 -> 12 and 13.5 -> 14), or 0 for NaN
+func (p DataPoint) PM25Int() int {
+	return roundInt(p.PM25)
+}
+
+// PM10Int returns the PM10 value rounded to the nearest whole μg / ㎥ (rounding
+// half to even, e.g. 12.5 -> 12 and 13.5 -> 14), or 0 for NaN
+func (p DataPoint) PM10Int() int {
+	return roundInt(p.PM10)
+}
+
+// RoundedCopy returns a copy of the data point with the PM2.5 / PM10 values rounded
+// to the nearest whole μg / ㎥ (rounding half to even), NaN values are retained
+func (p DataPoint) RoundedCopy() DataPoint {
+	p.PM25 = math.RoundToEven(p.PM25)
+	p.PM10 = math.RoundToEven(p.PM10)
+
+	return p
+}
+
+func roundInt(val float64) int {
+	if math.IsNaN(val) {
+		return 0
+	}
+
+	return int(math.RoundToEven(val))
+}
@@ -0,0 +1,41 @@
+package sds011
+
+import (
+	"math"
+	"testing"
+)
+
+func TestQuantizeBoundaries(t *testing.T) {
+	for _, cs := range []struct {
+		val, step, expected float64
+	}{
+		{12.24, 0.5, 12.0},
+		{12.25, 0.5, 12.5},
+		{12.74, 0.5, 12.5},
+		{12.75, 0.5, 13.0},
+		{12.34, 0.1, 12.3},
+		{12.35, 0.1, 12.4},
+		{0.05, 0.1, 0.1},
+		{12.49, 5, 10},
+		{12.5, 5, 15},
+		{999.9, 10, 1000},
+		{0, 5, 0},
+		{12.34, 0, 12.34},
+		{12.34, -1, 12.34},
+	} {
+		p := DataPoint{PM25: cs.val, PM10: cs.val}
+		q := p.Quantize(cs.step)
+		if q.PM25 != cs.expected || q.PM10 != cs.expected {
+			t.Fatalf("unexpected quantization of %v (step %v), want %v, have %v / %v", cs.val, cs.step, cs.expected, q.PM25, q.PM10)
+		}
+		if p.PM25 != cs.val || p.PM10 != cs.val {
+			t.Fatalf("original data point was modified")
+		}
+	}
+
+	// NaN values are retained
+	q := DataPoint{PM25: math.NaN(), PM10: 12.3}.Quantize(1)
+	if !math.IsNaN(q.PM25) || q.PM10 != 12 {
+		t.Fatalf("unexpected quantization, want NaN / 12, have %v / %v", q.PM25, q.PM10)
+	}
+}
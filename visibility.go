@@ -0,0 +1,31 @@
+package sds011
+
+const (
+
+	// koschmiederConstant denotes the constant of the Koschmieder relation
+	// (-ln(0.02) for a contrast threshold of 2%)
+	koschmiederConstant = 3.912
+
+	// rayleighExtinction denotes the extinction coefficient of particle-free air
+	// (in 1 / Mm)
+	rayleighExtinction = 10.
+)
+
+// VisibilityExtinctionEfficiency denotes the mass extinction efficiency of PM2.5
+// (in ㎡ / g) used to estimate the visibility (see VisibilityKm()). The default
+// is a typical value for urban aerosol, it may be adjusted to local conditions
+// (e.g. higher values for humid climates).
+var VisibilityExtinctionEfficiency = 3.75
+
+// VisibilityKm returns an estimate of the visual range (in km) based on the PM2.5
+// concentration, using the Koschmieder relation V = 3.912 / b_ext with the
+// extinction coefficient b_ext derived from Rayleigh scattering and the PM2.5
+// mass (see VisibilityExtinctionEfficiency)
+// NOTE: This is a rough approximation, the actual visibility strongly depends on
+// the composition of the aerosol, humidity and absorption by gases
+func (p *DataPoint) VisibilityKm() float64 {
+	extinction := rayleighExtinction + VisibilityExtinctionEfficiency*p.PM25
+
+	// The extinction coefficient is given in 1 / Mm, hence V = 3912 / b_ext in km
+	return koschmiederConstant * 1000. / extinction
+}
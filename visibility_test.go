@@ -0,0 +1,32 @@
+package sds011
+
+import (
+	"math"
+	"testing"
+)
+
+func TestVisibilityKm(t *testing.T) {
+
+	defer func(efficiency float64) {
+		VisibilityExtinctionEfficiency = efficiency
+	}(VisibilityExtinctionEfficiency)
+
+	for _, cs := range []struct {
+		pm25       float64
+		efficiency float64
+		expected   float64
+	}{
+		{0, 3.75, 391.2},
+		{10, 3.75, 82.357},
+		{100, 3.75, 10.161},
+		{500, 3.75, 2.0753},
+		{100, 5, 7.6706},
+	} {
+		VisibilityExtinctionEfficiency = cs.efficiency
+
+		p := DataPoint{PM25: cs.pm25}
+		if visibility := p.VisibilityKm(); math.Abs(visibility-cs.expected) > 1e-3 {
+			t.Fatalf("unexpected visibility for %v μg / ㎥ (efficiency %v), want %v km, have %v km", cs.pm25, cs.efficiency, cs.expected, visibility)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package sds011
+
+import "sync/atomic"
+
+// WakeZeroPolicy denotes how the first data frame after waking the device is
+// handled if it reports zero for both PM2.5 and PM10 (see WithWakeZeroPolicy())
+type WakeZeroPolicy int
+
+const (
+
+	// WakeZeroKeep reports the frame as is (default)
+	WakeZeroKeep WakeZeroPolicy = iota
+
+	// WakeZeroFlag reports the frame with the WarmingUp indicator set
+	WakeZeroFlag
+
+	// WakeZeroDiscard discards the frame and reads the next one instead
+	WakeZeroDiscard
+)
+
+// markWoken records that the device has just been woken up
+func (s *SDS011) markWoken() {
+	atomic.StoreInt32(&s.justWoken, 1)
+}
+
+// isWakeZero determines if a (validated) frame is the first one after waking the
+// device and reports zero for both PM2.5 and PM10 (based on the raw counts, i.e.
+// regardless of any scaling / calibration)
+func (s *SDS011) isWakeZero(rxData []byte) bool {
+	if s.wakeZeroPolicy == WakeZeroKeep || atomic.SwapInt32(&s.justWoken, 0) == 0 {
+		return false
+	}
+
+	count25, count10, err := DecodeCounts(rxData[2:6])
+
+	return err == nil && count25 == 0 && count10 == 0
+}
+
+// discardWakeZero determines if a data point has to be discarded as the first
+// (all-zero) reading after waking the device (releasing it if so)
+func (s *SDS011) discardWakeZero(dataPoint *DataPoint) bool {
+	if !dataPoint.WarmingUp || s.wakeZeroPolicy != WakeZeroDiscard {
+		return false
+	}
+	dataPoint.Release()
+
+	return true
+}
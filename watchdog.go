@@ -0,0 +1,229 @@
+package sds011
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// WatchdogConfig denotes the configuration of a Watchdog
+type WatchdogConfig struct {
+
+	// Open denotes the function used to open the sensor (and to re-open it if
+	// its connection cannot be re-established)
+	Open func() (Sensor, error)
+
+	// MaxFailures denotes the number of consecutive timeouts / transport errors
+	// after which the connection to the sensor is re-established
+	MaxFailures int
+
+	// MaxReconnects denotes the number of consecutive failed recoveries via
+	// Reconnect() after which the sensor is closed and re-opened via Open
+	MaxReconnects int
+
+	// MinBackoff denotes the initial time to wait before a recovery attempt,
+	// doubled after each attempt not followed by a successful read
+	MinBackoff time.Duration
+
+	// MaxBackoff denotes the upper limit of the time to wait before a recovery attempt
+	MaxBackoff time.Duration
+}
+
+// DefaultWatchdogConfig returns a watchdog configuration populated with sane
+// defaults for a sensor at the provided path
+func DefaultWatchdogConfig(path string) WatchdogConfig {
+	return WatchdogConfig{
+		Open: func() (Sensor, error) {
+			return New(path)
+		},
+		MaxFailures:   3,
+		MaxReconnects: 3,
+		MinBackoff:    time.Second,
+		MaxBackoff:    5 * time.Minute,
+	}
+}
+
+// WatchdogState denotes the current state of a Watchdog
+type WatchdogState struct {
+	Failures         int           // Number of consecutive timeouts / transport errors
+	FailedReconnects int           // Number of consecutive recoveries via Reconnect() not followed by a successful read
+	Backoff          time.Duration // Time to wait before the next recovery attempt
+	Reconnects       uint64        // Total number of recoveries via Reconnect()
+	Reopens          uint64        // Total number of recoveries by re-opening the sensor
+	LastErr          error         // Last error encountered (if any)
+}
+
+// Watchdog denotes a supervisor for unattended operation of a sensor: Consecutive
+// timeouts / transport errors are counted and, once the threshold is reached, the
+// connection is re-established (escalating to closing and re-opening the sensor if
+// that repeatedly fails), with an exponential backoff between recovery attempts
+type Watchdog struct {
+	cfg      WatchdogConfig
+	sensor   Sensor
+	isClosed bool
+
+	state WatchdogState
+	mutex sync.Mutex // Protects the sensor and the state (never held during I/O or backoff)
+
+	opMutex sync.Mutex // Serializes all operations on the sensor (including recoveries)
+}
+
+// NewWatchdog opens the sensor and creates a new Watchdog supervising it
+func NewWatchdog(cfg WatchdogConfig) (*Watchdog, error) {
+
+	if cfg.Open == nil {
+		return nil, fmt.Errorf("no function to open the sensor provided")
+	}
+	if cfg.MaxFailures < 1 || cfg.MaxReconnects < 1 {
+		return nil, fmt.Errorf("invalid thresholds, failures / reconnects must be at least 1, have %d / %d", cfg.MaxFailures, cfg.MaxReconnects)
+	}
+	if cfg.MaxBackoff < cfg.MinBackoff {
+		return nil, fmt.Errorf("maximum backoff (%v) must not be smaller than minimum backoff (%v)", cfg.MaxBackoff, cfg.MinBackoff)
+	}
+
+	sensor, err := cfg.Open()
+	if err != nil {
+		return nil, fmt.Errorf("error opening sensor: %w", err)
+	}
+
+	return &Watchdog{
+		cfg:    cfg,
+		sensor: sensor,
+		state: WatchdogState{
+			Backoff: cfg.MinBackoff,
+		},
+	}, nil
+}
+
+// QueryData extract the current PM2.5 and PM10 values from the sensor (see
+// Sensor.QueryDataContext()), recovering the sensor once the failure threshold
+// is reached (in which case the error of the failed query is returned)
+func (w *Watchdog) QueryData(ctx context.Context) (*DataPoint, error) {
+	return w.do(ctx, func(sensor Sensor) (*DataPoint, error) {
+		return sensor.QueryDataContext(ctx)
+	})
+}
+
+// WaitForData extract the current PM2.5 and PM10 values from the sensor (see
+// Sensor.WaitForDataContext()), recovering the sensor once the failure threshold
+// is reached (in which case the error of the failed read is returned)
+func (w *Watchdog) WaitForData(ctx context.Context) (*DataPoint, error) {
+	return w.do(ctx, func(sensor Sensor) (*DataPoint, error) {
+		return sensor.WaitForDataContext(ctx)
+	})
+}
+
+// State returns the current state of the watchdog
+func (w *Watchdog) State() WatchdogState {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.state
+}
+
+// Close puts the supervised sensor to sleep and closes it (an ongoing recovery
+// will not re-open it)
+func (w *Watchdog) Close() error {
+	w.mutex.Lock()
+	w.isClosed = true
+	sensor := w.sensor
+	w.mutex.Unlock()
+
+	return sensor.Shutdown()
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func (w *Watchdog) do(ctx context.Context, fn func(Sensor) (*DataPoint, error)) (*DataPoint, error) {
+	w.opMutex.Lock()
+	defer w.opMutex.Unlock()
+
+	w.mutex.Lock()
+	sensor := w.sensor
+	w.mutex.Unlock()
+
+	dataPoint, err := fn(sensor)
+
+	w.mutex.Lock()
+	if err == nil {
+		w.state.Failures, w.state.FailedReconnects = 0, 0
+		w.state.Backoff = w.cfg.MinBackoff
+		w.mutex.Unlock()
+		return dataPoint, nil
+	}
+
+	w.state.LastErr = err
+	if !errors.Is(err, ErrTimeout) && !IsTransportError(err) {
+		w.mutex.Unlock()
+		return nil, err
+	}
+	w.state.Failures++
+	failed, backoff := w.state.Failures >= w.cfg.MaxFailures, w.state.Backoff
+	w.mutex.Unlock()
+
+	if failed {
+		w.recover(ctx, backoff)
+	}
+
+	return nil, err
+}
+
+// recover re-establishes the connection to the sensor after the backoff period,
+// escalating to re-opening the sensor once the reconnect threshold is reached
+// NOTE: The state is only locked in between the individual steps, hence it can
+// be retrieved (and the watchdog closed) while waiting / recovering
+func (w *Watchdog) recover(ctx context.Context, backoff time.Duration) {
+
+	if err := sleepContext(ctx, backoff); err != nil {
+		return
+	}
+
+	w.mutex.Lock()
+	if w.isClosed {
+		w.mutex.Unlock()
+		return
+	}
+	w.state.Failures = 0
+	if w.state.Backoff *= 2; w.state.Backoff > w.cfg.MaxBackoff {
+		w.state.Backoff = w.cfg.MaxBackoff
+	}
+	sensor := w.sensor
+	r, ok := sensor.(reconnector)
+	reconnect := ok && w.state.FailedReconnects < w.cfg.MaxReconnects
+	if reconnect {
+		w.state.FailedReconnects++
+	}
+	w.mutex.Unlock()
+
+	if reconnect {
+		err := r.Reconnect()
+
+		w.mutex.Lock()
+		defer w.mutex.Unlock()
+		if err != nil {
+			w.state.LastErr = err
+			return
+		}
+		w.state.Reconnects++
+		return
+	}
+
+	sensor.Close() // #nosec G104
+	reopened, err := w.cfg.Open()
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if err != nil {
+		w.state.LastErr = fmt.Errorf("error re-opening sensor: %w", err)
+		return
+	}
+	if w.isClosed {
+		reopened.Shutdown() // #nosec G104
+		return
+	}
+	w.sensor = reopened
+	w.state.FailedReconnects = 0
+	w.state.Reopens++
+}
@@ -0,0 +1,64 @@
+package sds011
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWatchdogBackoffDoesNotBlock(t *testing.T) {
+
+	// A sleeping sensor times out on each query, triggering a recovery (with a
+	// long backoff) right away
+	s := newTestSimulatedSensor()
+	if err := s.SetWorkMode(WorkModeSleep); err != nil {
+		t.Fatalf("error putting sensor to sleep: %s", err)
+	}
+	w, err := NewWatchdog(WatchdogConfig{
+		Open: func() (Sensor, error) {
+			return s, nil
+		},
+		MaxFailures:   1,
+		MaxReconnects: 1,
+		MinBackoff:    time.Minute,
+		MaxBackoff:    time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("error creating watchdog: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error)
+	go func() {
+		_, err := w.QueryData(ctx)
+		done <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	// Both the state and closing the watchdog must be accessible while it
+	// is backing off
+	stateDone := make(chan WatchdogState)
+	go func() {
+		stateDone <- w.State()
+		stateDone <- WatchdogState{LastErr: w.Close()}
+	}()
+	select {
+	case state := <-stateDone:
+		if !errors.Is(state.LastErr, ErrTimeout) || state.Failures != 1 {
+			t.Fatalf("unexpected watchdog state: %+v", state)
+		}
+		if state := <-stateDone; state.LastErr != nil {
+			t.Fatalf("error closing watchdog: %s", state.LastErr)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("watchdog state blocked during backoff")
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, ErrTimeout) {
+		t.Fatalf("unexpected query error, want %v, have %v", ErrTimeout, err)
+	}
+}
@@ -0,0 +1,133 @@
+package sds011
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// WebhookSignatureHeader denotes the HTTP header carrying the HMAC-SHA256
+// signature of the request body (if a secret is configured)
+const WebhookSignatureHeader = "X-Signature-256"
+
+// WebhookSink denotes a Sink that POSTs each data point as JSON to an HTTP endpoint
+type WebhookSink struct {
+	url          string
+	client       *http.Client
+	retries      int
+	retryBackoff time.Duration
+	secret       []byte
+}
+
+// WebhookOption denotes a functional option for a WebhookSink
+type WebhookOption func(*WebhookSink)
+
+// WithWebhookTimeout sets the timeout for each individual HTTP request
+func WithWebhookTimeout(timeout time.Duration) WebhookOption {
+	return func(s *WebhookSink) {
+		s.client.Timeout = timeout
+	}
+}
+
+// WithWebhookRetries sets the number of retries (and the delay between them)
+// in case a request fails
+func WithWebhookRetries(retries int, backoff time.Duration) WebhookOption {
+	return func(s *WebhookSink) {
+		s.retries = retries
+		s.retryBackoff = backoff
+	}
+}
+
+// WithWebhookSecret enables signing of each request body using HMAC-SHA256,
+// the signature is provided in the WebhookSignatureHeader header
+func WithWebhookSecret(secret []byte) WebhookOption {
+	return func(s *WebhookSink) {
+		s.secret = secret
+	}
+}
+
+// WithWebhookClient sets a custom HTTP client to perform the requests
+func WithWebhookClient(client *http.Client) WebhookOption {
+	return func(s *WebhookSink) {
+		s.client = client
+	}
+}
+
+// NewWebhookSink creates a new WebhookSink posting to the provided URL
+func NewWebhookSink(url string, opts ...WebhookOption) *WebhookSink {
+	s := &WebhookSink{
+		url: url,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		retryBackoff: time.Second,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Write POSTs a single data point to the endpoint, retrying if requested
+func (s *WebhookSink) Write(p DataPoint) error {
+
+	body, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("error marshalling data point: %w", err)
+	}
+
+	for i := 0; ; i++ {
+		if err = s.post(body); err == nil || i >= s.retries {
+			break
+		}
+		time.Sleep(s.retryBackoff)
+	}
+
+	return err
+}
+
+// Close fulfills the Sink interface (there are no resources to release)
+func (s *WebhookSink) Close() error {
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func (s *WebhookSink) post(body []byte) error {
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if len(s.secret) > 0 {
+		mac := hmac.New(sha256.New, s.secret)
+		mac.Write(body) // #nosec G104
+		req.Header.Set(WebhookSignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error posting to webhook %s: %w", s.url, err)
+	}
+	defer resp.Body.Close()
+
+	// Drain the body to allow for connection reuse
+	io.Copy(io.Discard, resp.Body) // #nosec G104
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected webhook response status: %s", resp.Status)
+	}
+
+	return nil
+}